@@ -12,11 +12,18 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	gosync "sync"
 	"time"
 
 	"github.com/pires/go-proxyproto"
 
+	"elpsykongroo.com/monitor/pkg/check"
+	"elpsykongroo.com/monitor/pkg/connectivity"
+	"elpsykongroo.com/monitor/pkg/maintenance"
+	"elpsykongroo.com/monitor/pkg/metrics"
+	"elpsykongroo.com/monitor/pkg/notify"
 	"elpsykongroo.com/monitor/pkg/s3"
+	"elpsykongroo.com/monitor/pkg/schedule"
 	"elpsykongroo.com/monitor/pkg/types"
 	"elpsykongroo.com/monitor/pkg/vault"
 	"github.com/caddyserver/certmagic"
@@ -29,6 +36,9 @@ import (
 
 var logger = logrus.New()
 
+// resultLock keeps rows of concurrent checks and pushes from interleaving in the data file
+var resultLock gosync.Mutex
+
 func main() {
 	deviceId := getDeviceId()
 	homePath, err := os.UserHomeDir()
@@ -93,18 +103,108 @@ func main() {
 		c.String(http.StatusOK, clientIP)
 	})
 
+	scheduler := schedule.New()
+	r.GET("/schedule", func(c *gin.Context) {
+		c.JSON(http.StatusOK, scheduler.Jobs())
+	})
+
+	maintenancePath := generateDatapath(config.Name)
+	if maintenancePath != "" {
+		maintenancePath += "maintenance.json"
+	}
+	windows := maintenance.New(config.Maintenance, maintenancePath)
+	r.GET("/maintenance", func(c *gin.Context) {
+		if !isValidToken(c.GetHeader("Authorization"), *config) {
+			c.String(http.StatusUnauthorized, "invalid token")
+			return
+		}
+		c.JSON(http.StatusOK, windows.List())
+	})
+	r.POST("/maintenance", func(c *gin.Context) {
+		if !isValidToken(c.GetHeader("Authorization"), *config) {
+			c.String(http.StatusUnauthorized, "invalid token")
+			return
+		}
+		var window types.Maintenance
+		if err := c.ShouldBindJSON(&window); err != nil {
+			c.String(http.StatusBadRequest, "invalid maintenance window")
+			return
+		}
+		window, err := windows.Add(window)
+		if err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, window)
+	})
+	r.DELETE("/maintenance/:id", func(c *gin.Context) {
+		if !isValidToken(c.GetHeader("Authorization"), *config) {
+			c.String(http.StatusUnauthorized, "invalid token")
+			return
+		}
+		if !windows.Delete(c.Param("id")) {
+			c.String(http.StatusNotFound, "maintenance window not found")
+			return
+		}
+		c.String(http.StatusOK, "deleted")
+	})
+
+	alerter := notify.New(*config, deviceId, notifyRoutes(*config))
+	r.POST("/notify/preview", func(c *gin.Context) {
+		if !isValidToken(c.GetHeader("Authorization"), *config) {
+			c.String(http.StatusUnauthorized, "invalid token")
+			return
+		}
+		var preview types.TemplatePreview
+		if err := c.ShouldBindJSON(&preview); err != nil {
+			c.String(http.StatusBadRequest, "invalid template preview")
+			return
+		}
+		message, err := notify.Preview(config.Notifiers, preview, notify.SampleAlert(config.Name, deviceId))
+		if err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, message)
+	})
+	// record tags, stores and alerts every check result
+	record := func(result types.Result) {
+		result = windows.Apply(result, time.Now())
+		writeResult(deviceId, result, config.Name)
+		alerter.Observe(result)
+	}
+
 	r.PUT("/status", func(c *gin.Context) {
 		// if c.GetHeader("Authorization") != "" {
 		// 	if isValidToken(c.GetHeader("Authorization"), *config) {
-		writeCSV(c, deviceId, nil, config.Name)
+		writeCSV(c, deviceId, nil, config.Name, "")
 		// 	}
 		// }
 	})
 
+	if config.EnableHeartbeat {
+		logger.Info("enable heartbeat")
+		heartbeats := check.NewHeartbeats(config.Heartbeats, time.Now())
+		r.POST("/heartbeat/:token", func(c *gin.Context) {
+			failed := c.Query("status") == "fail"
+			result, ok := heartbeats.Beat(c.Param("token"), failed, time.Now())
+			if !ok {
+				c.String(http.StatusNotFound, "heartbeat not found")
+				return
+			}
+			record(result)
+			c.String(http.StatusOK, result.Status)
+		})
+		scheduleJob(scheduler, "heartbeat", *config, 10*time.Second, false, func() {
+			evaluateHeartbeats(heartbeats, record)
+		})
+	}
+
 	if config.EnableQuery {
 		logger.Info("enable status query")
 		r.GET("/status", func(c *gin.Context) {
 			statuses := readCSV(c, deviceId, *config)
+			targets := filterTargets(c.Query("target"), c.Query("tag"), *config)
 			var healthData []types.HealthData
 			var healthWithPrivateData []types.HealthWithPrivateData
 			var isPrivate bool
@@ -114,12 +214,52 @@ func main() {
 				}
 			}
 			for _, item := range statuses {
+				result, ok := parseResult(item)
+				if !ok {
+					continue
+				}
+				if targets != nil && !targets[result.Target] {
+					continue
+				}
+				var certExpiryDays *int
+				if result.Detail.Tls != nil && result.Detail.Tls.Certificate != nil {
+					certExpiryDays = &result.Detail.Tls.Certificate.ExpiryDays
+				}
 				if isPrivate {
 					healthWithPrivateData = append(
 						healthWithPrivateData,
-						types.HealthWithPrivateData{Timestamp: item[0], Status: item[1], Origin: item[2]})
+						types.HealthWithPrivateData{
+							Timestamp:      result.Timestamp,
+							Status:         result.Status,
+							Origin:         result.Origin,
+							Target:         result.Target,
+							Latency:        result.Latency,
+							Outcome:        result.Outcome,
+							CertExpiryDays: certExpiryDays,
+							Error:          result.Detail.Error,
+							Timing:         result.Detail.Timing,
+							Banner:         result.Detail.Banner,
+							Tls:            result.Detail.Tls,
+							Answers:        result.Detail.Answers,
+							Ping:           result.Detail.Ping,
+							Output:         result.Detail.Output,
+							Metrics:        result.Detail.Metrics,
+							Steps:          result.Detail.Steps,
+							Processes:      result.Detail.Processes,
+							Container:      result.Detail.Container,
+							Maintenance:    result.Detail.Maintenance,
+							RootCause:      result.Detail.RootCause,
+						})
 				} else {
-					healthData = append(healthData, types.HealthData{Timestamp: item[0], Status: item[1]})
+					healthData = append(healthData, types.HealthData{
+						Timestamp:      result.Timestamp,
+						Status:         result.Status,
+						Target:         result.Target,
+						Latency:        result.Latency,
+						Outcome:        result.Outcome,
+						CertExpiryDays: certExpiryDays,
+						RootCause:      result.Detail.RootCause,
+					})
 				}
 			}
 			if isPrivate {
@@ -128,26 +268,113 @@ func main() {
 				c.JSON(http.StatusOK, healthData)
 			}
 		})
+		r.GET("/status/uptime", func(c *gin.Context) {
+			statuses := readCSV(c, deviceId, *config)
+			targets := filterTargets(c.Query("target"), c.Query("tag"), *config)
+			var results []types.Result
+			for _, item := range statuses {
+				result, ok := parseResult(item)
+				if !ok {
+					continue
+				}
+				if targets != nil && !targets[result.Target] {
+					continue
+				}
+				results = append(results, result)
+			}
+			c.JSON(http.StatusOK, uptime(results))
+		})
 	}
 
 	if config.EnableCheck {
 		logger.Info("enable api check")
-		go checkAPIHealth(deviceId, *config)
+		go checkAPIHealth(scheduler, record, *config)
 	}
 
 	if config.EnableUpload {
 		logger.Info("enable status data upload")
-		go scheduleUploadStatus(generateDatapath(config.Name), deviceId, *config)
+		dataPath := generateDatapath(config.Name)
+		scheduleJob(scheduler, "upload", *config, time.Duration(config.UploadDuration)*time.Minute, true, func() {
+			uploadStatus(dataPath, deviceId, config.Endpoint, config.Bucket, config.Region)
+		})
 	}
 
 	if config.EnableSync {
 		logger.Info("enable data sync")
-		go sync(deviceId, *config)
+		scheduleJob(scheduler, "sync", *config, time.Duration(config.SyncDuration)*time.Minute, false, func() {
+			sync(deviceId, *config)
+		})
+	}
+
+	if config.EnableHostMetrics {
+		logger.Info("enable host metrics")
+		metricsDuration := config.MetricsDuration
+		if metricsDuration <= 0 {
+			metricsDuration = 60
+		}
+		scheduleJob(scheduler, "metrics", *config, time.Duration(metricsDuration)*time.Second, false, func() {
+			writeHostMetrics(metrics.Collect(), config.Name)
+		})
+		r.GET("/metrics/host", func(c *gin.Context) {
+			if !isValidToken(c.GetHeader("Authorization"), *config) {
+				c.String(http.StatusUnauthorized, "invalid token")
+				return
+			}
+			date := c.DefaultQuery("date", time.Now().Format("2006-01-02"))
+			if !isDate(date) {
+				c.String(http.StatusBadRequest, "invalid date")
+				return
+			}
+			samples, err := readHostMetrics(date, deviceId, *config)
+			if err != nil {
+				logger.Error("read host metrics err:", err)
+				c.JSON(http.StatusOK, []types.HostMetrics{})
+				return
+			}
+			if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 && limit < len(samples) {
+				samples = samples[len(samples)-limit:]
+			}
+			c.JSON(http.StatusOK, samples)
+		})
+	}
+
+	if config.EnableConnectivity {
+		logger.Info("enable connectivity monitor")
+		connectivityPath := generateDatapath(config.Name)
+		if connectivityPath != "" {
+			connectivityPath += "connectivity"
+		}
+		monitor := connectivity.New(*config, connectivityPath)
+		interval := config.Connectivity.Interval
+		if interval <= 0 {
+			interval = 30
+		}
+		scheduleJob(scheduler, "connectivity", *config, time.Duration(interval)*time.Second, true, func() {
+			record(monitor.Probe())
+		})
+		r.GET("/connectivity", func(c *gin.Context) {
+			if !isValidToken(c.GetHeader("Authorization"), *config) {
+				c.String(http.StatusUnauthorized, "invalid token")
+				return
+			}
+			var since time.Time
+			if date := c.Query("since"); date != "" {
+				if !isDate(date) {
+					c.String(http.StatusBadRequest, "invalid date")
+					return
+				}
+				since, _ = time.ParseInLocation("2006-01-02", date, time.Local)
+			}
+			c.JSON(http.StatusOK, monitor.Report(since))
+		})
 	}
 
 	if config.EnableIpCheck {
 		logger.Info("enable report install ip")
-		go reportIp(*config)
+		logger.Info("start report instance ip:", config.IpCheckUrl)
+		scheduleJob(scheduler, "report", *config, time.Duration(config.ReportDuration)*time.Hour, true, func() {
+			vault.ReportIpByCheck(*config)
+		})
 	}
 
 	if config.CfToken == "" {
@@ -246,6 +473,7 @@ func readSingleFile(filename string) ([][]string, error) {
 		}
 		defer file.Close()
 		reader := csv.NewReader(file)
+		reader.FieldsPerRecord = -1
 		status, err := reader.ReadAll()
 		if err != nil {
 			return nil, err
@@ -366,30 +594,27 @@ func isValidToken(token string, config types.Config) bool {
 
 func sync(deviceId string, config types.Config) {
 	logger.Info("sync option force:", config.ForceSync)
-	logger.Info("sync option duration:", config.SyncDuration)
-	for range time.Tick(time.Duration(config.SyncDuration) * time.Minute) {
-		//check s3
-		client := s3.InitS3(config.Endpoint, config.Bucket, config.Region)
-		basics := s3.BucketBasics{client}
-		bucketExist, err := basics.BucketExists(config.Bucket)
-		if err != nil {
-			logger.Error("BucketExists error:", err)
-		}
-		if !bucketExist {
-			basics.CreateBucket(config.Bucket, config.Region)
-		}
-		//
-		listObjects, err := basics.ListObjects(config.Bucket)
-		if err != nil {
-			logger.Error("listObject error:", err)
-		}
-		dataRemotePath := generateRemoteDatapath(config.Name)
+	//check s3
+	client := s3.InitS3(config.Endpoint, config.Bucket, config.Region)
+	basics := s3.BucketBasics{S3Client: client}
+	bucketExist, err := basics.BucketExists(config.Bucket)
+	if err != nil {
+		logger.Error("BucketExists error:", err)
+	}
+	if !bucketExist {
+		basics.CreateBucket(config.Bucket, config.Region)
+	}
+	//
+	listObjects, err := basics.ListObjects(config.Bucket)
+	if err != nil {
+		logger.Error("listObject error:", err)
+	}
+	dataRemotePath := generateRemoteDatapath(config.Name)
 
-		//sync with s3
-		for _, item := range listObjects {
-			if isDate(strings.Split(*item.Key, "_")[0]) {
-				basics.Download(config.Bucket, *item.Key, dataRemotePath+*item.Key, config.ForceSync)
-			}
+	//sync with s3
+	for _, item := range listObjects {
+		if isDate(strings.Split(*item.Key, "_")[0]) {
+			basics.Download(config.Bucket, *item.Key, dataRemotePath+*item.Key, config.ForceSync)
 		}
 	}
 }
@@ -441,7 +666,7 @@ func readCSV(c *gin.Context, deviceId string, config types.Config) [][]string {
 
 	//check s3
 	client := s3.InitS3(config.Endpoint, config.Bucket, config.Region)
-	basics := s3.BucketBasics{client}
+	basics := s3.BucketBasics{S3Client: client}
 	bucketExist, err := basics.BucketExists(config.Bucket)
 	if err != nil {
 		logger.Error("BucketExists error:", err)
@@ -495,6 +720,7 @@ func readCSV(c *gin.Context, deviceId string, config types.Config) [][]string {
 		}
 		defer file.Close()
 		reader := csv.NewReader(file)
+		reader.FieldsPerRecord = -1
 		status, err := reader.ReadAll()
 		statuses = append(statuses, status...)
 		if err != nil {
@@ -517,11 +743,14 @@ func readCSV(c *gin.Context, deviceId string, config types.Config) [][]string {
 	return statuses
 }
 
-func writeCSV(c *gin.Context, deviceId string, dataMap map[string][]string, name string) {
+func writeCSV(c *gin.Context, deviceId string, dataMap map[string][]string, name string, target string) {
 	dataPath := generateDatapath(name)
 	currentDate := time.Now()
 	formatData := currentDate.Format("2006-01-02")
 	if dataPath != "" {
+		// pushed rows share the daily file with check results
+		resultLock.Lock()
+		defer resultLock.Unlock()
 		file, err := os.OpenFile(dataPath+formatData, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 
 		if err != nil {
@@ -540,6 +769,10 @@ func writeCSV(c *gin.Context, deviceId string, dataMap map[string][]string, name
 				c.String(http.StatusBadRequest, "Failed to parse form data")
 				return
 			}
+			if target == "" {
+				target = c.Request.Form.Get("target")
+			}
+			c.Request.Form.Del("target")
 			data = c.Request.Form
 		} else {
 			origin = name + "_" + deviceId
@@ -547,7 +780,7 @@ func writeCSV(c *gin.Context, deviceId string, dataMap map[string][]string, name
 		}
 		for key, values := range data {
 			for _, value := range values {
-				err := writer.Write([]string{key, value, origin})
+				err := writer.Write([]string{key, value, origin, target})
 				if err != nil {
 					c.String(http.StatusInternalServerError, "Failed to write to CSV file")
 					return
@@ -557,47 +790,241 @@ func writeCSV(c *gin.Context, deviceId string, dataMap map[string][]string, name
 	}
 }
 
-func reportIp(config types.Config) {
-	logger.Info("start report instance ip:", config.IpCheckUrl)
-	vault.ReportIpByCheck(config)
-	for range time.Tick(time.Duration(config.ReportDuration) * time.Hour) {
-		vault.ReportIpByCheck(config)
+func writeResult(deviceId string, result types.Result, name string) {
+	resultLock.Lock()
+	defer resultLock.Unlock()
+	dataPath := generateDatapath(name)
+	if dataPath == "" {
+		return
 	}
-}
+	formatData := time.Now().Format("2006-01-02")
+	file, err := os.OpenFile(dataPath+formatData, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logger.Error("open data file err:", err)
+		return
+	}
+	defer file.Close()
 
-func checkAPIHealth(deviceId string, config types.Config) {
-	logger.Debug("start check health with:", config.MonitorUrl)
-	for range time.Tick(time.Duration(config.CheckDuration) * time.Second) {
-		resp, err := http.Get(config.MonitorUrl)
-		currentTime := time.Now()
-		currentTimeString := currentTime.Format("2006-01-02 15:04:05 -0700")
-		healthMap := make(map[string][]string)
+	var detail string
+	if result.Detail != nil {
+		detailJson, err := json.Marshal(result.Detail)
 		if err != nil {
-			logger.Error("Error checking API health:", err)
-			healthMap[currentTimeString] = []string{"500"}
-			writeCSV(nil, deviceId, healthMap, config.Name)
+			logger.Error("marshal detail err:", err)
 		} else {
-			if resp.StatusCode != http.StatusOK {
-				logger.Info("API is unhealthy! Status code:", resp.StatusCode)
-				satusCodeStr := strconv.Itoa(resp.StatusCode)
-				healthMap[currentTimeString] = []string{satusCodeStr}
-				writeCSV(nil, deviceId, healthMap, config.Name)
+			detail = string(detailJson)
+		}
+	}
+	result.Origin = name + "_" + deviceId
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+	err = writer.Write([]string{
+		result.Timestamp,
+		result.Status,
+		result.Origin,
+		result.Target,
+		strconv.FormatInt(result.Latency, 10),
+		result.Outcome,
+		detail,
+	})
+	if err != nil {
+		logger.Error("write result err:", err)
+	}
+}
+
+// parseResult reads a csv row, rows written by older versions only have the leading columns,
+// shorter rows are corrupt
+func parseResult(item []string) (types.Result, bool) {
+	if len(item) < 3 {
+		return types.Result{}, false
+	}
+	result := types.Result{Timestamp: item[0], Status: item[1], Origin: item[2], Detail: &types.Detail{}}
+	if len(item) > 3 {
+		result.Target = item[3]
+	}
+	if len(item) > 5 {
+		result.Latency, _ = strconv.ParseInt(item[4], 10, 64)
+		result.Outcome = item[5]
+	}
+	if len(item) > 6 && item[6] != "" {
+		err := json.Unmarshal([]byte(item[6]), result.Detail)
+		if err != nil {
+			logger.Error("parse detail err:", err)
+		}
+	}
+	return result, true
+}
+
+// uptime counts the outcomes per target, maintenance and legacy rows without outcome are not part of the percentage
+func uptime(results []types.Result) []types.Uptime {
+	uptimes := []types.Uptime{}
+	index := make(map[string]int)
+	for _, result := range results {
+		i, ok := index[result.Target]
+		if !ok {
+			i = len(uptimes)
+			index[result.Target] = i
+			uptimes = append(uptimes, types.Uptime{Target: result.Target})
+		}
+		u := &uptimes[i]
+		u.Total++
+		switch result.Outcome {
+		case types.OutcomeUp:
+			u.Up++
+		case types.OutcomeDegraded:
+			u.Degraded++
+		case types.OutcomeDown:
+			u.Down++
+		case types.OutcomeUnreachable:
+			u.Unreachable++
+		case types.OutcomeMaintenance:
+			u.Maintenance++
+		}
+	}
+	for i := range uptimes {
+		u := &uptimes[i]
+		if counted := u.Up + u.Degraded + u.Down + u.Unreachable; counted > 0 {
+			u.Uptime = float64(u.Up+u.Degraded) * 100 / float64(counted)
+		}
+	}
+	return uptimes
+}
+
+// scheduleJob runs the job on its entry in Config.Schedules, or every duration by default
+func scheduleJob(scheduler *schedule.Scheduler, name string, config types.Config, every time.Duration, immediate bool, run func()) {
+	err := scheduler.Add(name, config.Schedules[name], every, immediate, run)
+	if err != nil {
+		logger.Error("schedule job err:", name, " ", err)
+	}
+}
+
+func checkAPIHealth(scheduler *schedule.Scheduler, record func(types.Result), config types.Config) {
+	targets := monitorTargets(config)
+	dependencies := check.NewDependencies(targets)
+	for _, target := range targets {
+		run := checkTarget(target, dependencies, record, config)
+		// the target schedule wins over its entry in Config.Schedules
+		spec := target.Schedule
+		if spec.Spec == "" {
+			spec = config.Schedules["check:"+target.Name]
+		}
+		err := scheduler.Add("check:"+target.Name, spec, time.Duration(target.Interval)*time.Second, false, run)
+		if err != nil {
+			logger.Error("schedule check err, target:", target.Name, " err:", err)
+		}
+	}
+}
+
+func monitorTargets(config types.Config) []types.Target {
+	targets := append([]types.Target(nil), config.Targets...)
+	if len(targets) == 0 && config.MonitorUrl != "" {
+		targets = []types.Target{{Name: config.Name, Url: config.MonitorUrl}}
+	}
+	for i := range targets {
+		if targets[i].Name == "" {
+			targets[i].Name = targets[i].Url
+		}
+		if targets[i].Interval <= 0 {
+			targets[i].Interval = config.CheckDuration
+		}
+		if targets[i].Timeout <= 0 {
+			targets[i].Timeout = 10
+		}
+	}
+	return targets
+}
+
+// filterTargets returns the target names selected by the query, nil means no filter
+func filterTargets(name string, tag string, config types.Config) map[string]bool {
+	if name == "" && tag == "" {
+		return nil
+	}
+	selected := make(map[string]bool)
+	if name != "" {
+		selected[name] = true
+	}
+	if tag != "" {
+		for _, target := range monitorTargets(config) {
+			for _, t := range target.Tags {
+				if t == tag {
+					selected[target.Name] = true
+				}
+			}
+		}
+		for _, heartbeat := range config.Heartbeats {
+			for _, t := range heartbeat.Tags {
+				if t == tag {
+					selected[heartbeat.Name] = true
+				}
 			}
-			resp.Body.Close()
 		}
 	}
+	return selected
 }
 
-func scheduleUploadStatus(filePath string, deviceId string, config types.Config) {
-	uploadStatus(filePath, deviceId, config.Endpoint, config.Bucket, config.Region)
-	for range time.Tick(time.Duration(config.UploadDuration) * time.Minute) {
-		uploadStatus(filePath, deviceId, config.Endpoint, config.Bucket, config.Region)
+// notifyRoutes maps every target, heartbeat and the connectivity monitor to its notifiers and recipients
+func notifyRoutes(config types.Config) map[string]notify.Route {
+	routes := make(map[string]notify.Route)
+	for _, target := range monitorTargets(config) {
+		routes[target.Name] = notify.Route{Notify: target.Notify, Recipients: target.Recipients}
+	}
+	for _, heartbeat := range config.Heartbeats {
+		if heartbeat.Name != "" {
+			routes[heartbeat.Name] = notify.Route{Notify: heartbeat.Notify, Recipients: heartbeat.Recipients}
+		}
+	}
+	name := config.Connectivity.Name
+	if name == "" {
+		name = "internet"
+	}
+	routes[name] = notify.Route{Notify: config.Connectivity.Notify, Recipients: config.Connectivity.Recipients}
+	return routes
+}
+
+// resolveAuth fills the target credentials from vault when a vault path is configured
+func resolveAuth(target types.Target, config types.Config) types.Target {
+	if target.Auth.VaultPath == "" {
+		return target
+	}
+	secret := vault.ReadSecret(config, target.Auth.VaultPath)
+	if secret == nil {
+		logger.Error("resolve auth from vault failed, target:", target.Name)
+		return target
+	}
+	if secret["username"] != "" {
+		target.Auth.Username = secret["username"]
+	}
+	if secret["password"] != "" {
+		target.Auth.Password = secret["password"]
+	}
+	if secret["token"] != "" {
+		target.Auth.Token = secret["token"]
+	}
+	return target
+}
+
+// checkTarget prepares the client and state of the target and returns one check run
+func checkTarget(target types.Target, dependencies *check.Dependencies, record func(types.Result), config types.Config) func() {
+	logger.Debug("start check health with:", target.Url)
+	target = resolveAuth(target, config)
+	client := check.NewHTTPClient(target)
+	state := &check.State{FailureThreshold: target.FailureThreshold, SuccessThreshold: target.SuccessThreshold}
+	dependencies.Probe(target.Name, func() types.Result {
+		return check.Run(client, target)
+	})
+	return func() {
+		record(dependencies.Apply(state.Apply(check.Run(client, target))))
+	}
+}
+
+func evaluateHeartbeats(heartbeats *check.Heartbeats, record func(types.Result)) {
+	for _, result := range heartbeats.Evaluate(time.Now()) {
+		record(result)
 	}
 }
 
 func uploadStatus(filePath string, deviceId string, endpoint string, bucket string, region string) {
 	client := s3.InitS3(endpoint, bucket, region)
-	basics := s3.BucketBasics{client}
+	basics := s3.BucketBasics{S3Client: client}
 	currentTime := time.Now()
 	formatData := currentTime.Format("2006-01-02")
 	logger.Debug("list local dir:", filePath)
@@ -621,6 +1048,87 @@ func uploadStatus(filePath string, deviceId string, endpoint string, bucket stri
 			}
 		}
 	}
+	metricsFiles, err := os.ReadDir(filePath + "metrics/")
+	if err != nil {
+		return
+	}
+	for _, file := range metricsFiles {
+		if !file.IsDir() && isDate(file.Name()) {
+			err := basics.Upload(bucket, "metrics/"+file.Name()+"_"+deviceId, filePath+"metrics/"+file.Name())
+			if err != nil {
+				continue
+			}
+			if file.Name() != formatData {
+				os.Remove(filePath + "metrics/" + file.Name())
+			}
+		}
+	}
+}
+
+// metricsDatapath keeps one json line per host sample in a daily file
+func metricsDatapath(dataPath string) string {
+	if dataPath == "" {
+		return ""
+	}
+	err := os.MkdirAll(dataPath+"metrics/", 0755)
+	if err != nil {
+		logger.Error("create metrics dir err:", err)
+		return ""
+	}
+	return dataPath + "metrics/"
+}
+
+func writeHostMetrics(sample types.HostMetrics, name string) {
+	dataPath := metricsDatapath(generateDatapath(name))
+	if dataPath == "" {
+		return
+	}
+	data, err := json.Marshal(sample)
+	if err != nil {
+		logger.Error("marshal host metrics err:", err)
+		return
+	}
+	file, err := os.OpenFile(dataPath+time.Now().Format("2006-01-02"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logger.Error("open metrics file err:", err)
+		return
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	if err != nil {
+		logger.Error("write host metrics err:", err)
+	}
+}
+
+// readHostMetrics reads the local samples of the date, uploaded days are fetched from s3
+func readHostMetrics(date string, deviceId string, config types.Config) ([]types.HostMetrics, error) {
+	filename := metricsDatapath(generateDatapath(config.Name)) + date
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		client := s3.InitS3(config.Endpoint, config.Bucket, config.Region)
+		basics := s3.BucketBasics{S3Client: client}
+		filename = metricsDatapath(generateRemoteDatapath(config.Name)) + date
+		err := basics.Download(config.Bucket, "metrics/"+date+"_"+deviceId, filename, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var samples []types.HostMetrics
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		var sample types.HostMetrics
+		if err := json.Unmarshal([]byte(line), &sample); err != nil {
+			logger.Error("parse host metrics err:", err)
+			continue
+		}
+		samples = append(samples, sample)
+	}
+	return samples, nil
 }
 
 func wakeOnLAN(macAddr string) error {
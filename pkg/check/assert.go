@@ -0,0 +1,140 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"elpsykongroo.com/monitor/pkg/types"
+)
+
+// maxBodySize limits how much of the response body is kept for assertions
+const maxBodySize = 1 << 20
+
+// assertResponse returns the name of the first failed assertion and why it failed,
+// an empty name means every assertion passed
+func assertResponse(assertions types.Assertions, resp *http.Response, body []byte, latency int64) (string, string) {
+	if !statusAccepted(assertions.StatusCodes, resp.StatusCode) {
+		return "status_code", "unexpected status code " + strconv.Itoa(resp.StatusCode)
+	}
+	for name, value := range assertions.Headers {
+		header := resp.Header.Get(name)
+		if header == "" || !strings.Contains(header, value) {
+			return "header:" + name, "header " + name + " is " + strconv.Quote(header)
+		}
+	}
+	if assertions.BodyContains != "" && !bytes.Contains(body, []byte(assertions.BodyContains)) {
+		return "body_contains", "body does not contain " + strconv.Quote(assertions.BodyContains)
+	}
+	if assertions.BodyRegex != "" {
+		re, err := regexp.Compile(assertions.BodyRegex)
+		if err != nil {
+			return "body_regex", err.Error()
+		}
+		if !re.Match(body) {
+			return "body_regex", "body does not match " + assertions.BodyRegex
+		}
+	}
+	if len(assertions.JsonPath) > 0 {
+		var data interface{}
+		if err := json.Unmarshal(body, &data); err != nil {
+			return "json", err.Error()
+		}
+		for _, assertion := range assertions.JsonPath {
+			if name, reason := assertJson(data, assertion); name != "" {
+				return name, reason
+			}
+		}
+	}
+	if assertions.MaxResponseTime > 0 && latency > assertions.MaxResponseTime {
+		return "max_response_time", "response time " + strconv.FormatInt(latency, 10) + "ms"
+	}
+	return "", ""
+}
+
+func assertJson(data interface{}, assertion types.JsonAssertion) (string, string) {
+	value, ok := lookupJsonPath(data, assertion.Path)
+	if !ok {
+		return "json_path:" + assertion.Path, "path not exist"
+	}
+	if assertion.Equals != nil {
+		actual := jsonString(value)
+		if actual != *assertion.Equals {
+			return "json_path:" + assertion.Path, "value is " + strconv.Quote(actual)
+		}
+	}
+	return "", ""
+}
+
+// statusAccepted matches codes like "200", "2xx" or "200-299", default only 200 is accepted
+func statusAccepted(codes []string, statusCode int) bool {
+	if len(codes) == 0 {
+		return statusCode == http.StatusOK
+	}
+	status := strconv.Itoa(statusCode)
+	for _, code := range codes {
+		code = strings.ToLower(strings.TrimSpace(code))
+		if from, to, found := strings.Cut(code, "-"); found {
+			min, err := strconv.Atoi(from)
+			if err != nil {
+				continue
+			}
+			max, err := strconv.Atoi(to)
+			if err != nil {
+				continue
+			}
+			if statusCode >= min && statusCode <= max {
+				return true
+			}
+		} else if len(code) == 3 && strings.HasSuffix(code, "xx") {
+			if status[0] == code[0] {
+				return true
+			}
+		} else if code == status {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupJsonPath resolves paths like "$.data.items[0].status" or "data.items.0.status"
+func lookupJsonPath(data interface{}, path string) (interface{}, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.ReplaceAll(strings.ReplaceAll(path, "[", "."), "]", "")
+	for _, key := range strings.Split(path, ".") {
+		if key == "" {
+			continue
+		}
+		switch node := data.(type) {
+		case map[string]interface{}:
+			value, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			data = value
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			data = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return data, true
+}
+
+func jsonString(value interface{}) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,124 @@
+package check
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/types"
+)
+
+// Run probes the target, failed probes are retried with the delay doubled each time
+func Run(client *http.Client, target types.Target) types.Result {
+	delay := time.Duration(target.RetryDelay) * time.Millisecond
+	if delay <= 0 {
+		delay = time.Second
+	}
+	attempts := 1
+	result := probe(client, target)
+	for ; attempts <= target.Retries && result.Outcome == types.OutcomeDown; attempts++ {
+		logger.Info("retry check, target:", target.Name, " attempt:", attempts)
+		time.Sleep(delay)
+		delay *= 2
+		result = probe(client, target)
+	}
+	if result.Detail == nil {
+		result.Detail = &types.Detail{}
+	}
+	result.Detail.Attempts = attempts
+	return result
+}
+
+// newResult is a down result of the target stamped at start, every check fills it in as it goes
+func newResult(target types.Target, start time.Time) types.Result {
+	return types.Result{
+		Timestamp: start.Format("2006-01-02 15:04:05 -0700"),
+		Target:    target.Name,
+		Outcome:   types.OutcomeDown,
+		Detail:    &types.Detail{},
+	}
+}
+
+func probe(client *http.Client, target types.Target) types.Result {
+	switch target.Type {
+	case types.CheckTCP:
+		return TCP(target)
+	case types.CheckTLS:
+		return certExpiry(TLS(target), target)
+	case types.CheckDNS:
+		return DNS(target)
+	case types.CheckICMP:
+		return ICMP(target)
+	case types.CheckExec:
+		return Exec(target)
+	case types.CheckTransaction:
+		return Transaction(target)
+	case types.CheckGRPC:
+		return GRPC(target)
+	case types.CheckWebSocket:
+		return WebSocket(target)
+	case types.CheckProcess:
+		return Process(target)
+	case types.CheckContainer:
+		return Container(target)
+	default:
+		return certExpiry(HTTP(client, target), target)
+	}
+}
+
+// certExpiry marks a healthy result degraded when the leaf certificate expires within CertExpiryDays,
+// the window defaults to 14 days and a negative value disables it
+func certExpiry(result types.Result, target types.Target) types.Result {
+	if result.Outcome != types.OutcomeUp || result.Detail == nil || result.Detail.Tls == nil || result.Detail.Tls.Certificate == nil {
+		return result
+	}
+	window := target.CertExpiryDays
+	if window == 0 {
+		window = 14
+	}
+	days := result.Detail.Tls.Certificate.ExpiryDays
+	if window > 0 && days <= window {
+		logger.Warn("certificate expires soon, target:", target.Name, " days:", days)
+		result.Outcome = types.OutcomeDegraded
+		result.Detail.Error = "certificate expires in " + strconv.Itoa(days) + " days"
+	}
+	return result
+}
+
+// State confirms outcome transitions of a target, the stored outcome only
+// changes after FailureThreshold failures or SuccessThreshold successes in a row
+type State struct {
+	Outcome          string
+	FailureThreshold int
+	SuccessThreshold int
+	failures         int
+	successes        int
+}
+
+// Apply counts the probe outcome and rewrites the result outcome to the confirmed one
+func (s *State) Apply(result types.Result) types.Result {
+	probe := result.Outcome
+	if probe == types.OutcomeDown {
+		s.failures++
+		s.successes = 0
+	} else {
+		s.successes++
+		s.failures = 0
+	}
+	switch {
+	case s.Outcome == "":
+		s.Outcome = probe
+	case probe == types.OutcomeDown && s.failures >= s.FailureThreshold:
+		s.Outcome = probe
+	case probe != types.OutcomeDown && s.successes >= s.SuccessThreshold:
+		s.Outcome = probe
+	}
+	if s.Outcome != probe {
+		if result.Detail == nil {
+			result.Detail = &types.Detail{}
+		}
+		result.Detail.ProbeOutcome = probe
+		result.Outcome = s.Outcome
+	}
+	return result
+}
@@ -0,0 +1,100 @@
+package check
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/types"
+)
+
+const dockerSocket = "/var/run/docker.sock"
+
+type dockerContainer struct {
+	Id           string `json:"Id"`
+	RestartCount int    `json:"RestartCount"`
+	Config       struct {
+		Image string `json:"Image"`
+	} `json:"Config"`
+	State struct {
+		Status    string `json:"Status"`
+		StartedAt string `json:"StartedAt"`
+		Health    *struct {
+			Status string `json:"Status"`
+		} `json:"Health"`
+	} `json:"State"`
+}
+
+// Container inspects the container through the docker engine api on the unix socket
+// in target.Url ("unix:///var/run/docker.sock" by default), it is up when running
+// and healthy, a starting health check is degraded
+func Container(target types.Target) types.Result {
+	start := time.Now()
+	result := newResult(target, start)
+	socket := strings.TrimPrefix(target.Url, "unix://")
+	if socket == "" {
+		socket = dockerSocket
+	}
+	client := &http.Client{
+		Timeout: time.Duration(target.Timeout) * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+	resp, err := client.Get("http://docker/containers/" + url.PathEscape(target.Container) + "/json")
+	result.Latency = time.Since(start).Milliseconds()
+	if err != nil {
+		logger.Error("docker api err, target:", target.Name, " err:", err)
+		result.Status = "connect"
+		result.Detail.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		result.Status = "connect"
+		result.Detail.Error = err.Error()
+		return result
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		result.Status = "not_found"
+		result.Detail.Error = "container " + target.Container + " not found"
+		return result
+	}
+	var container dockerContainer
+	if resp.StatusCode != http.StatusOK || json.Unmarshal(body, &container) != nil {
+		result.Status = "docker_api"
+		result.Detail.Error = strings.TrimSpace(string(body))
+		return result
+	}
+	info := &types.ContainerInfo{
+		Id:           container.Id,
+		Image:        container.Config.Image,
+		State:        container.State.Status,
+		StartedAt:    container.State.StartedAt,
+		RestartCount: container.RestartCount,
+	}
+	if container.State.Health != nil {
+		info.Health = container.State.Health.Status
+	}
+	result.Detail.Container = info
+	result.Status = info.State
+	if info.Health != "" {
+		result.Status = info.Health
+	}
+	switch {
+	case info.State != "running":
+	case info.Health == "" || info.Health == "healthy":
+		result.Outcome = types.OutcomeUp
+	case info.Health == "starting":
+		result.Outcome = types.OutcomeDegraded
+	}
+	return result
+}
@@ -0,0 +1,75 @@
+package check
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"elpsykongroo.com/monitor/pkg/types"
+)
+
+// fakeDocker serves /containers/{id}/json from containers on a temp unix socket,
+// an unknown id gets 404 like the docker engine
+func fakeDocker(t *testing.T, containers map[string]string) string {
+	t.Helper()
+	// a short dir keeps the socket path under the unix limit
+	dir, err := os.MkdirTemp("", "docker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/containers/"), "/json")
+		body, ok := containers[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"No such container: ` + id + `"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	})}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+	return "unix://" + socket
+}
+
+func TestContainer(t *testing.T) {
+	socket := fakeDocker(t, map[string]string{
+		"web":     `{"Id":"a1","RestartCount":2,"Config":{"Image":"nginx"},"State":{"Status":"running","StartedAt":"2024-01-01T00:00:00Z","Health":{"Status":"healthy"}}}`,
+		"plain":   `{"Id":"b2","Config":{"Image":"redis"},"State":{"Status":"running"}}`,
+		"booting": `{"Id":"c3","Config":{"Image":"app"},"State":{"Status":"running","Health":{"Status":"starting"}}}`,
+		"stopped": `{"Id":"d4","Config":{"Image":"job"},"State":{"Status":"exited"}}`,
+	})
+	tests := []struct {
+		container string
+		status    string
+		outcome   string
+	}{
+		{"web", "healthy", types.OutcomeUp},
+		{"plain", "running", types.OutcomeUp},
+		{"booting", "starting", types.OutcomeDegraded},
+		{"stopped", "exited", types.OutcomeDown},
+		{"missing", "not_found", types.OutcomeDown},
+	}
+	for _, test := range tests {
+		result := Container(types.Target{Name: test.container, Url: socket, Container: test.container, Timeout: 5})
+		if result.Status != test.status || result.Outcome != test.outcome {
+			t.Errorf("container %s: got status %q outcome %q, want %q %q", test.container, result.Status, result.Outcome, test.status, test.outcome)
+		}
+	}
+
+	result := Container(types.Target{Name: "web", Url: socket, Container: "web", Timeout: 5})
+	info := result.Detail.Container
+	if info == nil || info.Id != "a1" || info.Image != "nginx" || info.RestartCount != 2 || info.StartedAt != "2024-01-01T00:00:00Z" {
+		t.Errorf("container info: got %+v", info)
+	}
+}
@@ -0,0 +1,101 @@
+package check
+
+import (
+	"strings"
+	"sync"
+
+	"elpsykongroo.com/monitor/pkg/types"
+)
+
+// Dependencies keeps the confirmed outcome of every target so a failed child
+// can be told apart from a parent that is down
+type Dependencies struct {
+	mu       sync.Mutex
+	parents  map[string][]string
+	outcomes map[string]string
+	probes   map[string]func() types.Result
+}
+
+func NewDependencies(targets []types.Target) *Dependencies {
+	d := &Dependencies{
+		parents:  make(map[string][]string),
+		outcomes: make(map[string]string),
+		probes:   make(map[string]func() types.Result),
+	}
+	names := make(map[string]bool)
+	for _, target := range targets {
+		names[target.Name] = true
+	}
+	for _, target := range targets {
+		for _, parent := range target.Parents {
+			if !names[parent] {
+				logger.Warn("unknown parent, target:", target.Name, " parent:", parent)
+				continue
+			}
+			d.parents[target.Name] = append(d.parents[target.Name], parent)
+		}
+	}
+	return d
+}
+
+// Probe registers the check run of a target, it confirms a parent that has not
+// failed yet when a child goes down in the same round
+func (d *Dependencies) Probe(name string, probe func() types.Result) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.probes[name] = probe
+}
+
+// Apply records the outcome of the target, a down result is marked unreachable
+// with the root cause chain when a parent is down or unreachable itself
+func (d *Dependencies) Apply(result types.Result) types.Result {
+	if result.Outcome == types.OutcomeDown {
+		chain := d.downChain(result.Target, map[string]bool{result.Target: true})
+		if len(chain) > 0 {
+			if result.Detail == nil {
+				result.Detail = &types.Detail{}
+			}
+			if result.Detail.ProbeOutcome == "" {
+				result.Detail.ProbeOutcome = result.Outcome
+			}
+			result.Outcome = types.OutcomeUnreachable
+			result.Detail.RootCause = chain
+			result.Detail.Error = "unreachable (parent down): " + strings.Join(chain, " -> ")
+		}
+	}
+	d.mu.Lock()
+	d.outcomes[result.Target] = result.Outcome
+	d.mu.Unlock()
+	return result
+}
+
+// downChain follows the first failing parent up to the root cause, visited guards against cycles
+func (d *Dependencies) downChain(name string, visited map[string]bool) []string {
+	d.mu.Lock()
+	parents := d.parents[name]
+	d.mu.Unlock()
+	for _, parent := range parents {
+		if visited[parent] || !d.down(parent) {
+			continue
+		}
+		visited[parent] = true
+		return append([]string{parent}, d.downChain(parent, visited)...)
+	}
+	return nil
+}
+
+// down reports a parent recorded down, a parent recorded up or not checked yet is probed
+// again since every target runs on its own schedule and may not have noticed the failure
+func (d *Dependencies) down(name string) bool {
+	d.mu.Lock()
+	outcome := d.outcomes[name]
+	probe := d.probes[name]
+	d.mu.Unlock()
+	if outcome == types.OutcomeDown || outcome == types.OutcomeUnreachable {
+		return true
+	}
+	if probe == nil {
+		return false
+	}
+	return probe().Outcome == types.OutcomeDown
+}
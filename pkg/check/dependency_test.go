@@ -0,0 +1,54 @@
+package check
+
+import (
+	"reflect"
+	"testing"
+
+	"elpsykongroo.com/monitor/pkg/types"
+)
+
+func TestDependencies(t *testing.T) {
+	targets := []types.Target{
+		{Name: "router"},
+		{Name: "switch", Parents: []string{"router"}},
+		{Name: "web", Parents: []string{"switch"}},
+		{Name: "db", Parents: []string{"switch"}},
+	}
+	tests := []struct {
+		name     string
+		recorded map[string]string
+		probed   map[string]string
+		outcome  string
+		chain    []string
+	}{
+		{"parent recorded down", map[string]string{"router": types.OutcomeDown, "switch": types.OutcomeUnreachable}, nil, types.OutcomeUnreachable, []string{"switch", "router"}},
+		{"parent down before its own run", map[string]string{"router": types.OutcomeUp, "switch": types.OutcomeUp}, map[string]string{"router": types.OutcomeDown, "switch": types.OutcomeDown}, types.OutcomeUnreachable, []string{"switch", "router"}},
+		{"parent not checked yet", nil, map[string]string{"router": types.OutcomeUp, "switch": types.OutcomeDown}, types.OutcomeUnreachable, []string{"switch"}},
+		{"parent up", map[string]string{"switch": types.OutcomeUp}, map[string]string{"router": types.OutcomeUp, "switch": types.OutcomeUp}, types.OutcomeDown, nil},
+	}
+	for _, test := range tests {
+		d := NewDependencies(targets)
+		for name, outcome := range test.probed {
+			outcome := outcome
+			d.Probe(name, func() types.Result { return types.Result{Outcome: outcome} })
+		}
+		for name, outcome := range test.recorded {
+			d.Apply(types.Result{Target: name, Outcome: outcome})
+		}
+		result := d.Apply(types.Result{Target: "web", Outcome: types.OutcomeDown, Detail: &types.Detail{Error: "timeout"}})
+		if result.Outcome != test.outcome || !reflect.DeepEqual(result.Detail.RootCause, test.chain) {
+			t.Errorf("%s: got %s %v, want %s %v", test.name, result.Outcome, result.Detail.RootCause, test.outcome, test.chain)
+		}
+		if test.chain != nil && result.Detail.ProbeOutcome != types.OutcomeDown {
+			t.Errorf("%s: got probe outcome %q", test.name, result.Detail.ProbeOutcome)
+		}
+	}
+
+	// a child that is up never probes its parents
+	d := NewDependencies(targets)
+	d.Probe("switch", func() types.Result {
+		t.Error("parent probed for a child that is up")
+		return types.Result{}
+	})
+	d.Apply(types.Result{Target: "web", Outcome: types.OutcomeUp})
+}
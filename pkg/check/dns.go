@@ -0,0 +1,110 @@
+package check
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/types"
+	"github.com/miekg/dns"
+)
+
+// DNS queries the resolver for the target name and checks the expected answers exist
+func DNS(target types.Target) types.Result {
+	start := time.Now()
+	result := newResult(target, start)
+	recordType, ok := dns.StringToType[strings.ToUpper(target.RecordType)]
+	if target.RecordType == "" {
+		recordType, ok = dns.TypeA, true
+	}
+	if !ok {
+		result.Status = "record_type"
+		result.Detail.Error = "unsupported record type " + target.RecordType
+		return result
+	}
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(target.Url), recordType)
+	client := &dns.Client{Timeout: time.Duration(target.Timeout) * time.Second}
+	resp, rtt, err := client.Exchange(msg, resolver(target.Resolver))
+	result.Latency = time.Since(start).Milliseconds()
+	if err != nil {
+		logger.Error("dns query err, target:", target.Name, " err:", err)
+		result.Status = "query"
+		result.Detail.Error = err.Error()
+		return result
+	}
+	result.Latency = rtt.Milliseconds()
+	result.Status = dns.RcodeToString[resp.Rcode]
+	for _, rr := range resp.Answer {
+		if rr.Header().Rrtype == recordType {
+			result.Detail.Answers = append(result.Detail.Answers, answer(rr))
+		}
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		result.Detail.Error = "query failed with " + result.Status
+		return result
+	}
+	if len(result.Detail.Answers) == 0 {
+		result.Status = "answers"
+		result.Detail.Error = "no " + dns.TypeToString[recordType] + " record"
+		return result
+	}
+	for _, expected := range target.Answers {
+		if !containsAnswer(result.Detail.Answers, expected) {
+			result.Status = "answers"
+			result.Detail.Error = "answer " + expected + " not found"
+			return result
+		}
+	}
+	maxResponseTime := target.Assertions.MaxResponseTime
+	if maxResponseTime > 0 && result.Latency > maxResponseTime {
+		result.Status = "max_response_time"
+		result.Detail.Error = "response time " + strconv.FormatInt(result.Latency, 10) + "ms"
+		return result
+	}
+	result.Outcome = types.OutcomeUp
+	return result
+}
+
+// resolver defaults to the first nameserver in resolv.conf
+func resolver(address string) string {
+	if address == "" {
+		config, err := dns.ClientConfigFromFile("/etc/resolv.conf")
+		if err != nil || len(config.Servers) == 0 {
+			return "1.1.1.1:53"
+		}
+		return net.JoinHostPort(config.Servers[0], config.Port)
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return net.JoinHostPort(address, "53")
+	}
+	return address
+}
+
+func answer(rr dns.RR) string {
+	switch record := rr.(type) {
+	case *dns.A:
+		return record.A.String()
+	case *dns.AAAA:
+		return record.AAAA.String()
+	case *dns.CNAME:
+		return strings.TrimSuffix(record.Target, ".")
+	case *dns.MX:
+		return strconv.Itoa(int(record.Preference)) + " " + strings.TrimSuffix(record.Mx, ".")
+	case *dns.TXT:
+		return strings.Join(record.Txt, "")
+	default:
+		return strings.TrimPrefix(rr.String(), rr.Header().String())
+	}
+}
+
+func containsAnswer(answers []string, expected string) bool {
+	expected = strings.TrimSuffix(expected, ".")
+	for _, answer := range answers {
+		if strings.EqualFold(answer, expected) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package check
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/types"
+)
+
+// nagios plugin exit codes
+var pluginStates = []string{"OK", "WARNING", "CRITICAL", "UNKNOWN"}
+
+// maxOutputSize limits the plugin output kept in a result
+const maxOutputSize = 4096
+
+// Exec runs a nagios compatible plugin, exit code 0/1/2/3 means OK/WARNING/CRITICAL/UNKNOWN
+func Exec(target types.Target) types.Result {
+	start := time.Now()
+	result := newResult(target, start)
+	if len(target.Command) == 0 {
+		result.Status = "UNKNOWN"
+		result.Detail.Error = "command is empty"
+		return result
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(target.Timeout)*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, target.Command[0], target.Command[1:]...)
+	cmd.Env = os.Environ()
+	for key, value := range target.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	// children of the plugin may hold the output pipe after it is killed, stop waiting for them
+	cmd.WaitDelay = time.Second
+	killProcessGroup(cmd)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+	result.Latency = time.Since(start).Milliseconds()
+
+	text, metrics := parsePluginOutput(output.String())
+	if len(text) > maxOutputSize {
+		text = text[:maxOutputSize]
+	}
+	result.Detail.Output = strings.ToValidUTF8(text, "")
+	result.Detail.Metrics = metrics
+
+	code := 0
+	if ctx.Err() == context.DeadlineExceeded {
+		logger.Error("plugin timeout, target:", target.Name)
+		result.Status = "CRITICAL"
+		result.Detail.Error = "plugin timeout"
+		return result
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			logger.Error("run plugin err, target:", target.Name, " err:", err)
+			result.Status = "UNKNOWN"
+			result.Detail.Error = err.Error()
+			return result
+		}
+		code = exitErr.ExitCode()
+	}
+	if code < 0 || code >= len(pluginStates) {
+		code = 3
+	}
+	result.Status = pluginStates[code]
+	switch code {
+	case 0:
+		result.Outcome = types.OutcomeUp
+	case 1:
+		result.Outcome = types.OutcomeDegraded
+	}
+	return result
+}
+
+// parsePluginOutput splits the text from the perfdata after "|", perfdata may
+// also follow "|" in the long output lines
+func parsePluginOutput(output string) (string, []types.Metric) {
+	var text []string
+	var metrics []types.Metric
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		line, perfdata, found := strings.Cut(line, "|")
+		text = append(text, strings.TrimSpace(line))
+		if found {
+			metrics = append(metrics, parsePerfdata(perfdata)...)
+		}
+	}
+	return strings.TrimSpace(strings.Join(text, "\n")), metrics
+}
+
+// parsePerfdata reads items like 'label'=value[UOM];[warn];[crit];[min];[max]
+func parsePerfdata(perfdata string) []types.Metric {
+	var metrics []types.Metric
+	for _, item := range splitPerfdata(perfdata) {
+		label, data, found := strings.Cut(item, "=")
+		if !found {
+			continue
+		}
+		fields := strings.Split(data, ";")
+		value := strings.TrimRightFunc(fields[0], func(r rune) bool {
+			return !(r >= '0' && r <= '9' || r == '.')
+		})
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		metric := types.Metric{
+			Label: strings.Trim(label, "'"),
+			Value: number,
+			Unit:  fields[0][len(value):],
+		}
+		for i, field := range fields[1:] {
+			switch i {
+			case 0:
+				metric.Warn = field
+			case 1:
+				metric.Crit = field
+			case 2:
+				metric.Min = field
+			case 3:
+				metric.Max = field
+			}
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics
+}
+
+// splitPerfdata splits on spaces outside of single quoted labels
+func splitPerfdata(perfdata string) []string {
+	var items []string
+	var item strings.Builder
+	quoted := false
+	for _, r := range perfdata {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+			item.WriteRune(r)
+		case r == ' ' && !quoted:
+			if item.Len() > 0 {
+				items = append(items, item.String())
+				item.Reset()
+			}
+		default:
+			item.WriteRune(r)
+		}
+	}
+	if item.Len() > 0 {
+		items = append(items, item.String())
+	}
+	return items
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package check
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup starts the plugin in its own process group and kills the whole group on timeout
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package check
+
+import "os/exec"
+
+// killProcessGroup keeps the default kill of the plugin, WaitDelay releases the pipes of its children
+func killProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,48 @@
+package check
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// GRPC calls grpc.health.v1.Health/Check for the service, "grpcs://" urls use tls
+func GRPC(target types.Target) types.Result {
+	start := time.Now()
+	result := newResult(target, start)
+	creds := insecure.NewCredentials()
+	if strings.HasPrefix(target.Url, "grpcs://") {
+		creds = credentials.NewTLS(TlsConfig(target.Tls))
+	}
+	conn, err := grpc.NewClient(hostPort(target.Url, ""), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		logger.Error("grpc client err, target:", target.Name, " err:", err)
+		result.Status = "connect"
+		result.Detail.Error = err.Error()
+		return result
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(target.Timeout)*time.Second)
+	defer cancel()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: target.Service})
+	result.Latency = time.Since(start).Milliseconds()
+	if err != nil {
+		logger.Error("grpc health check err, target:", target.Name, " err:", err)
+		result.Status = status.Code(err).String()
+		result.Detail.Error = err.Error()
+		return result
+	}
+	result.Status = resp.GetStatus().String()
+	if resp.GetStatus() == healthpb.HealthCheckResponse_SERVING {
+		result.Outcome = types.OutcomeUp
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+package check
+
+import (
+	"sync"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/types"
+)
+
+// Heartbeats tracks the check-in deadline of every push monitor
+type Heartbeats struct {
+	mu        sync.Mutex
+	monitors  map[string]types.Heartbeat
+	deadlines map[string]time.Time
+}
+
+func NewHeartbeats(heartbeats []types.Heartbeat, now time.Time) *Heartbeats {
+	h := &Heartbeats{
+		monitors:  make(map[string]types.Heartbeat),
+		deadlines: make(map[string]time.Time),
+	}
+	for _, heartbeat := range heartbeats {
+		// the name is public in status and alerts, the token never stands in for it
+		if heartbeat.Name == "" || heartbeat.Token == "" || heartbeat.Period <= 0 {
+			logger.Warn("skip heartbeat without name, token or period:", heartbeat.Name)
+			continue
+		}
+		h.monitors[heartbeat.Token] = heartbeat
+		h.deadlines[heartbeat.Token] = now.Add(window(heartbeat))
+	}
+	return h
+}
+
+func window(heartbeat types.Heartbeat) time.Duration {
+	return time.Duration(heartbeat.Period+heartbeat.Grace) * time.Second
+}
+
+// Beat records a check-in, failed means the job reported its own failure
+func (h *Heartbeats) Beat(token string, failed bool, now time.Time) (types.Result, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	heartbeat, ok := h.monitors[token]
+	if !ok {
+		return types.Result{}, false
+	}
+	h.deadlines[token] = now.Add(window(heartbeat))
+	result := types.Result{
+		Timestamp: now.Format("2006-01-02 15:04:05 -0700"),
+		Status:    "ok",
+		Target:    heartbeat.Name,
+		Outcome:   types.OutcomeUp,
+		Detail:    &types.Detail{},
+	}
+	if failed {
+		result.Status = "fail"
+		result.Outcome = types.OutcomeDown
+		result.Detail.Error = "job reported failure"
+	}
+	return result, true
+}
+
+// Evaluate returns a failure for every monitor past its deadline,
+// a missing monitor fails again each period until it checks in
+func (h *Heartbeats) Evaluate(now time.Time) []types.Result {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var results []types.Result
+	for token, deadline := range h.deadlines {
+		if !now.After(deadline) {
+			continue
+		}
+		heartbeat := h.monitors[token]
+		logger.Warn("heartbeat missed:", heartbeat.Name)
+		results = append(results, types.Result{
+			Timestamp: now.Format("2006-01-02 15:04:05 -0700"),
+			Status:    "missed",
+			Target:    heartbeat.Name,
+			Outcome:   types.OutcomeDown,
+			Detail:    &types.Detail{Error: "no check-in before " + deadline.Format("2006-01-02 15:04:05 -0700")},
+		})
+		h.deadlines[token] = deadline.Add(time.Duration(heartbeat.Period) * time.Second)
+	}
+	return results
+}
@@ -0,0 +1,202 @@
+package check
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.New()
+
+// phases records the httptrace callbacks, dial callbacks may fire from other goroutines
+type phases struct {
+	mu           sync.Mutex
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+}
+
+func (p *phases) mark(t *time.Time) {
+	p.mu.Lock()
+	if t.IsZero() {
+		*t = time.Now()
+	}
+	p.mu.Unlock()
+}
+
+func (p *phases) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { p.mark(&p.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { p.mark(&p.dnsDone) },
+		ConnectStart:         func(string, string) { p.mark(&p.connectStart) },
+		ConnectDone:          func(string, string, error) { p.mark(&p.connectDone) },
+		TLSHandshakeStart:    func() { p.mark(&p.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { p.mark(&p.tlsDone) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { p.mark(&p.wroteRequest) },
+		GotFirstResponseByte: func() { p.mark(&p.firstByte) },
+	}
+}
+
+func (p *phases) timing(end time.Time) *types.Timing {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return &types.Timing{
+		DNS:       milliseconds(p.dnsStart, p.dnsDone),
+		Connect:   milliseconds(p.connectStart, p.connectDone),
+		TLS:       milliseconds(p.tlsStart, p.tlsDone),
+		FirstByte: milliseconds(p.wroteRequest, p.firstByte),
+		Transfer:  milliseconds(p.firstByte, end),
+	}
+}
+
+func milliseconds(start time.Time, end time.Time) float64 {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return float64(end.Sub(start).Microseconds()) / 1000
+}
+
+// NewHTTPClient builds the client used for every probe of the target,
+// keep-alive is disabled so each probe pays for dns, connect and tls again
+func NewHTTPClient(target types.Target) *http.Client {
+	maxRedirects := target.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = 10
+	}
+	return &http.Client{
+		Timeout: time.Duration(target.Timeout) * time.Second,
+		Transport: &http.Transport{
+			Proxy:             http.ProxyFromEnvironment,
+			DisableKeepAlives: true,
+			TLSClientConfig:   TlsConfig(target.Tls),
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if target.NoRedirect {
+				return http.ErrUseLastResponse
+			}
+			if len(via) >= maxRedirects {
+				return errors.New("stopped after " + strconv.Itoa(maxRedirects) + " redirects")
+			}
+			return nil
+		},
+	}
+}
+
+// TlsConfig builds the client tls config, a ca file that cannot be read leaves the system roots
+func TlsConfig(options types.TlsOptions) *tls.Config {
+	config := &tls.Config{
+		InsecureSkipVerify: options.InsecureSkipVerify,
+		ServerName:         options.ServerName,
+	}
+	if options.CaFile != "" {
+		ca, err := os.ReadFile(options.CaFile)
+		if err != nil {
+			logger.Error("read ca file err:", err)
+			return config
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			logger.Error("no certificate found in ca file:", options.CaFile)
+			return config
+		}
+		config.RootCAs = pool
+	}
+	return config
+}
+
+func newRequest(target types.Target) (*http.Request, error) {
+	method := target.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	if target.Body != "" {
+		body = strings.NewReader(target.Body)
+	}
+	req, err := http.NewRequest(strings.ToUpper(method), target.Url, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range target.Headers {
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+		} else {
+			req.Header.Set(name, value)
+		}
+	}
+	switch strings.ToLower(target.Auth.Type) {
+	case "basic":
+		req.SetBasicAuth(target.Auth.Username, target.Auth.Password)
+	case "bearer":
+		req.Header.Set("Authorization", "Bearer "+target.Auth.Token)
+	}
+	return req, nil
+}
+
+func HTTP(client *http.Client, target types.Target) types.Result {
+	result, _, _ := doHTTP(client, target)
+	return result
+}
+
+// doHTTP also returns the response and the body for captures, the response is nil when the request failed
+func doHTTP(client *http.Client, target types.Target) (types.Result, *http.Response, []byte) {
+	start := time.Now()
+	result := newResult(target, start)
+	req, err := newRequest(target)
+	if err != nil {
+		logger.Error("build request err, target:", target.Name, " err:", err)
+		result.Status = "500"
+		result.Detail = &types.Detail{Error: err.Error()}
+		return result, nil, nil
+	}
+	p := &phases{}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), p.trace()))
+	resp, err := client.Do(req)
+	if err != nil {
+		logger.Error("Error checking API health, target:", target.Name, " err:", err)
+		result.Status = "500"
+		result.Latency = time.Since(start).Milliseconds()
+		result.Detail = &types.Detail{Error: err.Error(), Timing: p.timing(time.Now())}
+		return result, nil, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	end := time.Now()
+	result.Latency = end.Sub(start).Milliseconds()
+	result.Status = strconv.Itoa(resp.StatusCode)
+	result.Detail = &types.Detail{StatusCode: resp.StatusCode, Timing: p.timing(end)}
+	if resp.TLS != nil {
+		result.Detail.Tls = tlsInfo(*resp.TLS)
+	}
+	if err != nil {
+		logger.Error("read body err, target:", target.Name, " err:", err)
+		result.Detail.Error = err.Error()
+		return result, resp, body
+	}
+	name, reason := assertResponse(target.Assertions, resp, body, result.Latency)
+	if name != "" {
+		logger.Info("API is unhealthy! target:", target.Name, " assertion:", name, " ", reason)
+		result.Status = name
+		result.Detail.Error = reason
+		return result, resp, body
+	}
+	result.Outcome = types.OutcomeUp
+	return result, resp, body
+}
@@ -0,0 +1,159 @@
+package check
+
+import (
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/types"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// ICMP sends a burst of echo requests over an unprivileged datagram socket,
+// linux only allows it for groups in net.ipv4.ping_group_range
+func ICMP(target types.Target) types.Result {
+	start := time.Now()
+	result := newResult(target, start)
+	host := target.Url
+	if strings.Contains(host, "://") {
+		if u, err := url.Parse(host); err == nil {
+			host = u.Hostname()
+		}
+	}
+	ip, err := net.ResolveIPAddr("ip", host)
+	if err != nil {
+		logger.Error("resolve host err, target:", target.Name, " err:", err)
+		result.Status = "resolve"
+		result.Detail.Error = err.Error()
+		return result
+	}
+	network, address, request, protocol := "udp4", "0.0.0.0", icmp.Type(ipv4.ICMPTypeEcho), 1
+	if ip.IP.To4() == nil {
+		network, address, request, protocol = "udp6", "::", ipv6.ICMPTypeEchoRequest, 58
+	}
+	conn, err := icmp.ListenPacket(network, address)
+	if err != nil {
+		logger.Error("icmp listen err, target:", target.Name, " err:", err)
+		result.Status = "socket"
+		result.Detail.Error = err.Error()
+		return result
+	}
+	defer conn.Close()
+
+	count := target.Count
+	if count <= 0 {
+		count = 4
+	}
+	// the whole burst is bounded by the target timeout, echoes left when it passes are not sent
+	deadline := start.Add(time.Duration(target.Timeout) * time.Second)
+	wait := time.Duration(target.Timeout) * time.Second / time.Duration(count)
+	if wait < time.Second {
+		wait = time.Second
+	}
+	var rtts []float64
+	sent := 0
+	for seq := 0; seq < count; seq++ {
+		if seq > 0 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		if !time.Now().Before(deadline) {
+			break
+		}
+		sent++
+		replyDeadline := time.Now().Add(wait)
+		if replyDeadline.After(deadline) {
+			replyDeadline = deadline
+		}
+		rtt, err := echo(conn, &net.UDPAddr{IP: ip.IP, Zone: ip.Zone}, request, protocol, seq, replyDeadline)
+		if err != nil {
+			logger.Debug("icmp echo err, target:", target.Name, " seq:", seq, " err:", err)
+			result.Detail.Error = err.Error()
+			continue
+		}
+		rtts = append(rtts, rtt)
+	}
+	stats := pingStats(sent, rtts)
+	result.Detail.Ping = stats
+	result.Latency = int64(math.Round(stats.Avg))
+	switch {
+	case stats.Received == 0:
+		result.Status = "packet_loss"
+	case stats.Received < stats.Sent:
+		result.Status = "packet_loss"
+		result.Outcome = types.OutcomeDegraded
+	default:
+		result.Status = "ok"
+		result.Outcome = types.OutcomeUp
+		result.Detail.Error = ""
+	}
+	return result
+}
+
+// echo sends one request and waits for the reply with the same sequence until deadline,
+// the kernel owns the echo id of datagram sockets so only the sequence is matched
+func echo(conn *icmp.PacketConn, dst net.Addr, request icmp.Type, protocol int, seq int, deadline time.Time) (float64, error) {
+	msg := icmp.Message{
+		Type: request,
+		Body: &icmp.Echo{ID: os.Getpid() & 0xffff, Seq: seq, Data: []byte("monitor")},
+	}
+	data, err := msg.Marshal(nil)
+	if err != nil {
+		return 0, err
+	}
+	sent := time.Now()
+	if _, err := conn.WriteTo(data, dst); err != nil {
+		return 0, err
+	}
+	conn.SetReadDeadline(deadline)
+	buf := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return 0, err
+		}
+		reply, err := icmp.ParseMessage(protocol, buf[:n])
+		if err != nil {
+			continue
+		}
+		if reply.Type != ipv4.ICMPTypeEchoReply && reply.Type != ipv6.ICMPTypeEchoReply {
+			continue
+		}
+		if body, ok := reply.Body.(*icmp.Echo); ok && body.Seq == seq {
+			return milliseconds(sent, time.Now()), nil
+		}
+	}
+}
+
+func pingStats(sent int, rtts []float64) *types.PingStats {
+	stats := &types.PingStats{
+		Sent:     sent,
+		Received: len(rtts),
+		Loss:     100,
+	}
+	if sent > 0 {
+		stats.Loss = float64(sent-len(rtts)) * 100 / float64(sent)
+	}
+	if len(rtts) == 0 {
+		return stats
+	}
+	stats.Min, stats.Max = rtts[0], rtts[0]
+	var sum, diff float64
+	for i, rtt := range rtts {
+		sum += rtt
+		stats.Min = math.Min(stats.Min, rtt)
+		stats.Max = math.Max(stats.Max, rtt)
+		if i > 0 {
+			diff += math.Abs(rtt - rtts[i-1])
+		}
+	}
+	stats.Avg = math.Round(sum/float64(len(rtts))*1000) / 1000
+	if len(rtts) > 1 {
+		stats.Jitter = math.Round(diff/float64(len(rtts)-1)*1000) / 1000
+	}
+	return stats
+}
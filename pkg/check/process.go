@@ -0,0 +1,97 @@
+package check
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/types"
+	"github.com/shirou/gopsutil/process"
+)
+
+// Process is up when at least one process matches the pid file, name or cmdline
+func Process(target types.Target) types.Result {
+	start := time.Now()
+	result := newResult(target, start)
+	processes, err := matchProcesses(target.Process)
+	result.Latency = time.Since(start).Milliseconds()
+	if err != nil {
+		logger.Error("match process err, target:", target.Name, " err:", err)
+		result.Status = "process"
+		result.Detail.Error = err.Error()
+		return result
+	}
+	for _, p := range processes {
+		info := types.ProcessInfo{Pid: p.Pid}
+		info.Name, _ = p.Name()
+		info.Cpu, _ = p.CPUPercent()
+		if memory, err := p.MemoryInfo(); err == nil {
+			info.Rss = memory.RSS
+		}
+		result.Detail.Processes = append(result.Detail.Processes, info)
+	}
+	if len(processes) == 0 {
+		result.Status = "not_running"
+		result.Detail.Error = "no process matched"
+		return result
+	}
+	result.Status = "running"
+	result.Outcome = types.OutcomeUp
+	return result
+}
+
+func matchProcesses(match types.ProcessMatch) ([]*process.Process, error) {
+	if match.Pidfile != "" {
+		data, err := os.ReadFile(match.Pidfile)
+		if err != nil {
+			return nil, err
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, err
+		}
+		exists, err := process.PidExists(int32(pid))
+		if err != nil || !exists {
+			return nil, err
+		}
+		p, err := process.NewProcess(int32(pid))
+		if err != nil {
+			return nil, err
+		}
+		return []*process.Process{p}, nil
+	}
+	var cmdline *regexp.Regexp
+	if match.Cmdline != "" {
+		re, err := regexp.Compile(match.Cmdline)
+		if err != nil {
+			return nil, err
+		}
+		cmdline = re
+	}
+	processes, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+	var matched []*process.Process
+	for _, p := range processes {
+		if match.Name != "" {
+			name, err := p.Name()
+			if err != nil || name != match.Name {
+				continue
+			}
+		}
+		if cmdline != nil {
+			line, err := p.Cmdline()
+			if err != nil || !cmdline.MatchString(line) {
+				continue
+			}
+		}
+		if match.Name == "" && cmdline == nil {
+			continue
+		}
+		matched = append(matched, p)
+	}
+	return matched, nil
+}
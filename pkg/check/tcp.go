@@ -0,0 +1,159 @@
+package check
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/types"
+)
+
+// maxBannerSize limits how much is read from the connection while waiting for expect
+const maxBannerSize = 4096
+
+// TCP connects to host:port and optionally sends data and matches the reply against expect
+func TCP(target types.Target) types.Result {
+	start := time.Now()
+	result := newResult(target, start)
+	timeout := time.Duration(target.Timeout) * time.Second
+	conn, err := net.DialTimeout("tcp", hostPort(target.Url, ""), timeout)
+	result.Detail.Timing = &types.Timing{Connect: milliseconds(start, time.Now())}
+	if err != nil {
+		logger.Error("tcp connect err, target:", target.Name, " err:", err)
+		result.Status = "connect"
+		result.Detail.Error = err.Error()
+		result.Latency = time.Since(start).Milliseconds()
+		return result
+	}
+	defer conn.Close()
+	conn.SetDeadline(start.Add(timeout))
+	result = exchange(conn, target, result)
+	result.Latency = time.Since(start).Milliseconds()
+	return result
+}
+
+// TLS completes a handshake and records the protocol, cipher and peer certificate
+func TLS(target types.Target) types.Result {
+	start := time.Now()
+	result := newResult(target, start)
+	timeout := time.Duration(target.Timeout) * time.Second
+	address := hostPort(target.Url, "443")
+	rawConn, err := net.DialTimeout("tcp", address, timeout)
+	connected := time.Now()
+	result.Detail.Timing = &types.Timing{Connect: milliseconds(start, connected)}
+	if err != nil {
+		logger.Error("tls connect err, target:", target.Name, " err:", err)
+		result.Status = "connect"
+		result.Detail.Error = err.Error()
+		result.Latency = time.Since(start).Milliseconds()
+		return result
+	}
+	defer rawConn.Close()
+	rawConn.SetDeadline(start.Add(timeout))
+	config := TlsConfig(target.Tls)
+	if config.ServerName == "" {
+		config.ServerName, _, _ = net.SplitHostPort(address)
+	}
+	conn := tls.Client(rawConn, config)
+	err = conn.Handshake()
+	result.Detail.Timing.TLS = milliseconds(connected, time.Now())
+	if err != nil {
+		logger.Error("tls handshake err, target:", target.Name, " err:", err)
+		result.Status = "handshake"
+		result.Detail.Error = err.Error()
+		result.Latency = time.Since(start).Milliseconds()
+		return result
+	}
+	result.Detail.Tls = tlsInfo(conn.ConnectionState())
+	result = exchange(conn, target, result)
+	result.Latency = time.Since(start).Milliseconds()
+	return result
+}
+
+// exchange writes target.Send and waits until the reply matches target.Expect
+func exchange(conn net.Conn, target types.Target, result types.Result) types.Result {
+	if target.Send != "" {
+		_, err := conn.Write([]byte(target.Send))
+		if err != nil {
+			result.Status = "send"
+			result.Detail.Error = err.Error()
+			return result
+		}
+	}
+	if target.Expect == "" {
+		result.Status = "ok"
+		result.Outcome = types.OutcomeUp
+		return result
+	}
+	re, err := regexp.Compile(target.Expect)
+	if err != nil {
+		result.Status = "expect"
+		result.Detail.Error = err.Error()
+		return result
+	}
+	var banner []byte
+	buf := make([]byte, 512)
+	for len(banner) < maxBannerSize {
+		n, err := conn.Read(buf)
+		banner = append(banner, buf[:n]...)
+		if re.Match(banner) {
+			result.Status = "ok"
+			result.Outcome = types.OutcomeUp
+			break
+		}
+		if err != nil {
+			result.Status = "expect"
+			result.Detail.Error = "banner does not match " + target.Expect + ", " + err.Error()
+			break
+		}
+	}
+	if result.Status == "" {
+		result.Status = "expect"
+		result.Detail.Error = "banner does not match " + target.Expect
+	}
+	result.Detail.Banner = strings.ToValidUTF8(string(banner), "")
+	return result
+}
+
+func tlsInfo(state tls.ConnectionState) *types.TlsInfo {
+	info := &types.TlsInfo{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+	}
+	if len(state.PeerCertificates) > 0 {
+		info.Certificate = certificate(state.PeerCertificates[0])
+	}
+	return info
+}
+
+func certificate(cert *x509.Certificate) *types.Certificate {
+	return &types.Certificate{
+		Subject:    cert.Subject.String(),
+		Issuer:     cert.Issuer.String(),
+		NotBefore:  cert.NotBefore.Format("2006-01-02 15:04:05 -0700"),
+		NotAfter:   cert.NotAfter.Format("2006-01-02 15:04:05 -0700"),
+		DNSNames:   cert.DNSNames,
+		ExpiryDays: int(time.Until(cert.NotAfter).Hours() / 24),
+	}
+}
+
+// hostPort accepts host:port or an url, the port of https urls defaults to 443
+func hostPort(raw string, defaultPort string) string {
+	if strings.Contains(raw, "://") {
+		u, err := url.Parse(raw)
+		if err == nil {
+			if u.Port() != "" || defaultPort == "" {
+				return u.Host
+			}
+			return net.JoinHostPort(u.Hostname(), defaultPort)
+		}
+	}
+	if _, _, err := net.SplitHostPort(raw); err != nil && defaultPort != "" {
+		return net.JoinHostPort(raw, defaultPort)
+	}
+	return raw
+}
@@ -0,0 +1,120 @@
+package check
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/types"
+)
+
+// Transaction runs the steps in order with a shared cookie jar, values captured
+// from a response are available to the following steps as "${name}"
+func Transaction(target types.Target) types.Result {
+	start := time.Now()
+	result := newResult(target, start)
+	result.Status = "ok"
+	result.Outcome = types.OutcomeUp
+	client := NewHTTPClient(target)
+	client.Jar, _ = cookiejar.New(nil)
+	variables := make(map[string]string)
+	for i, step := range target.Steps {
+		name := step.Name
+		if name == "" {
+			name = "step" + strconv.Itoa(i+1)
+		}
+		stepResult, resp, body := doHTTP(client, stepTarget(target, step, variables))
+		record := types.StepResult{
+			Name:    name,
+			Status:  stepResult.Status,
+			Latency: stepResult.Latency,
+			Outcome: stepResult.Outcome,
+			Error:   stepResult.Detail.Error,
+			Timing:  stepResult.Detail.Timing,
+		}
+		if stepResult.Outcome == types.OutcomeUp {
+			for _, c := range step.Capture {
+				value, err := capture(c, resp, body)
+				if err != nil {
+					record.Status = "capture:" + c.Name
+					record.Outcome = types.OutcomeDown
+					record.Error = err.Error()
+					break
+				}
+				variables[c.Name] = value
+			}
+		}
+		result.Detail.Steps = append(result.Detail.Steps, record)
+		if record.Outcome != types.OutcomeUp {
+			logger.Info("transaction failed, target:", target.Name, " step:", name, " status:", record.Status)
+			result.Status = name + ":" + record.Status
+			result.Outcome = types.OutcomeDown
+			result.Detail.Error = name + ": " + record.Error
+			break
+		}
+	}
+	result.Latency = time.Since(start).Milliseconds()
+	return result
+}
+
+// stepTarget builds the request of a step, tls, auth and timeout are inherited from the target
+func stepTarget(target types.Target, step types.Step, variables map[string]string) types.Target {
+	pairs := make([]string, 0, len(variables)*2)
+	for key, value := range variables {
+		pairs = append(pairs, "${"+key+"}", value)
+	}
+	replacer := strings.NewReplacer(pairs...)
+	headers := make(map[string]string)
+	for key, value := range target.Headers {
+		headers[key] = replacer.Replace(value)
+	}
+	for key, value := range step.Headers {
+		headers[key] = replacer.Replace(value)
+	}
+	target.Method = step.Method
+	target.Url = replacer.Replace(step.Url)
+	target.Body = replacer.Replace(step.Body)
+	target.Headers = headers
+	target.Assertions = step.Assertions
+	return target
+}
+
+func capture(c types.Capture, resp *http.Response, body []byte) (string, error) {
+	switch {
+	case c.JsonPath != "":
+		var data interface{}
+		if err := json.Unmarshal(body, &data); err != nil {
+			return "", err
+		}
+		value, ok := lookupJsonPath(data, c.JsonPath)
+		if !ok {
+			return "", errors.New("path " + c.JsonPath + " not exist")
+		}
+		return jsonString(value), nil
+	case c.Header != "":
+		value := resp.Header.Get(c.Header)
+		if value == "" {
+			return "", errors.New("header " + c.Header + " not exist")
+		}
+		return value, nil
+	case c.Regex != "":
+		re, err := regexp.Compile(c.Regex)
+		if err != nil {
+			return "", err
+		}
+		match := re.FindSubmatch(body)
+		if match == nil {
+			return "", errors.New("body does not match " + c.Regex)
+		}
+		if len(match) > 1 {
+			return string(match[1]), nil
+		}
+		return string(match[0]), nil
+	}
+	return "", errors.New("capture source is empty")
+}
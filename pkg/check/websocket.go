@@ -0,0 +1,84 @@
+package check
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/types"
+	"golang.org/x/net/websocket"
+)
+
+// WebSocket opens the connection, optionally sends target.Send and waits for
+// a message matching target.Expect
+func WebSocket(target types.Target) types.Result {
+	start := time.Now()
+	result := newResult(target, start)
+	origin := strings.Replace(target.Url, "ws", "http", 1)
+	config, err := websocket.NewConfig(target.Url, origin)
+	if err != nil {
+		result.Status = "connect"
+		result.Detail.Error = err.Error()
+		return result
+	}
+	config.TlsConfig = TlsConfig(target.Tls)
+	for name, value := range target.Headers {
+		config.Header.Set(name, value)
+	}
+	timeout := time.Duration(target.Timeout) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	conn, err := config.DialContext(ctx)
+	result.Detail.Timing = &types.Timing{Connect: milliseconds(start, time.Now())}
+	if err != nil {
+		logger.Error("websocket connect err, target:", target.Name, " err:", err)
+		result.Status = "connect"
+		result.Detail.Error = err.Error()
+		result.Latency = time.Since(start).Milliseconds()
+		return result
+	}
+	defer conn.Close()
+	conn.SetDeadline(start.Add(timeout))
+	result = receive(conn, target, result)
+	result.Latency = time.Since(start).Milliseconds()
+	return result
+}
+
+func receive(conn *websocket.Conn, target types.Target, result types.Result) types.Result {
+	if target.Send != "" {
+		if err := websocket.Message.Send(conn, target.Send); err != nil {
+			result.Status = "send"
+			result.Detail.Error = err.Error()
+			return result
+		}
+	}
+	if target.Expect == "" {
+		result.Status = "ok"
+		result.Outcome = types.OutcomeUp
+		return result
+	}
+	re, err := regexp.Compile(target.Expect)
+	if err != nil {
+		result.Status = "expect"
+		result.Detail.Error = err.Error()
+		return result
+	}
+	for {
+		var message string
+		if err := websocket.Message.Receive(conn, &message); err != nil {
+			result.Status = "expect"
+			result.Detail.Error = "no message matches " + target.Expect + ", " + err.Error()
+			return result
+		}
+		if len(message) > maxBannerSize {
+			message = message[:maxBannerSize]
+		}
+		result.Detail.Banner = strings.ToValidUTF8(message, "")
+		if re.MatchString(message) {
+			result.Status = "ok"
+			result.Outcome = types.OutcomeUp
+			return result
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package connectivity
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/check"
+	"elpsykongroo.com/monitor/pkg/types"
+	"github.com/go-resty/resty/v2"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.New()
+
+const (
+	EventOutage   = "outage"
+	EventIpChange = "ip"
+)
+
+// defaultEndpoints are dns resolvers of different providers, reached over tcp
+var defaultEndpoints = []types.Target{
+	{Type: types.CheckTCP, Name: "cloudflare", Url: "1.1.1.1:53"},
+	{Type: types.CheckTCP, Name: "google", Url: "8.8.8.8:53"},
+	{Type: types.CheckTCP, Name: "quad9", Url: "9.9.9.9:53"},
+}
+
+// Monitor keeps the current outage and public ip, finished events are appended to path as json lines
+type Monitor struct {
+	mu         sync.Mutex
+	name       string
+	endpoints  []types.Target
+	clients    []*http.Client
+	quorum     int
+	ipCheckUrl string
+	ipInterval time.Duration
+	path       string
+	outage     *types.ConnectivityEvent
+	ip         string
+	ipChecked  time.Time
+}
+
+func New(config types.Config, path string) *Monitor {
+	settings := config.Connectivity
+	m := &Monitor{
+		name:       settings.Name,
+		endpoints:  append([]types.Target(nil), settings.Endpoints...),
+		quorum:     settings.Quorum,
+		ipCheckUrl: config.IpCheckUrl,
+		ipInterval: time.Duration(settings.IpInterval) * time.Second,
+		path:       path,
+	}
+	if m.name == "" {
+		m.name = "internet"
+	}
+	if len(m.endpoints) == 0 {
+		m.endpoints = append([]types.Target(nil), defaultEndpoints...)
+	}
+	if m.quorum <= 0 || m.quorum > len(m.endpoints) {
+		m.quorum = len(m.endpoints)/2 + 1
+	}
+	if m.ipInterval <= 0 {
+		m.ipInterval = time.Hour
+	}
+	for i := range m.endpoints {
+		if m.endpoints[i].Name == "" {
+			m.endpoints[i].Name = m.endpoints[i].Url
+		}
+		if m.endpoints[i].Timeout <= 0 {
+			m.endpoints[i].Timeout = 5
+		}
+		m.clients = append(m.clients, check.NewHTTPClient(m.endpoints[i]))
+	}
+	for _, event := range m.events() {
+		if event.To != "" {
+			m.ip = event.To
+		}
+	}
+	return m
+}
+
+// Probe checks every endpoint at once and returns the round as a status row of the monitor
+func (m *Monitor) Probe() types.Result {
+	start := time.Now()
+	results := make([]types.Result, len(m.endpoints))
+	var wg sync.WaitGroup
+	for i := range m.endpoints {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = check.Run(m.clients[i], m.endpoints[i])
+		}(i)
+	}
+	wg.Wait()
+
+	var failed []string
+	for _, result := range results {
+		if result.Outcome == types.OutcomeDown {
+			failed = append(failed, result.Target)
+		}
+	}
+	now := time.Now()
+	result := types.Result{
+		Timestamp: start.Format("2006-01-02 15:04:05 -0700"),
+		Status:    "online",
+		Target:    m.name,
+		Latency:   now.Sub(start).Milliseconds(),
+		Outcome:   types.OutcomeUp,
+		Detail:    &types.Detail{},
+	}
+	// below the quorum the internet is up, the failed endpoints are only noted
+	if len(failed) > 0 {
+		result.Detail.Error = "failed endpoints: " + strings.Join(failed, ",")
+	}
+	down := len(failed) >= m.quorum
+	if down {
+		result.Status = "offline"
+		result.Outcome = types.OutcomeDown
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if down {
+		if m.outage == nil {
+			logger.Warn("internet down, failed endpoints:", failed)
+			m.outage = &types.ConnectivityEvent{Type: EventOutage, Start: start.Format("2006-01-02 15:04:05 -0700"), From: m.ip}
+		}
+		m.outage.Failed = failed
+		return result
+	}
+	if m.outage != nil {
+		outageStart, _ := time.Parse("2006-01-02 15:04:05 -0700", m.outage.Start)
+		m.outage.End = start.Format("2006-01-02 15:04:05 -0700")
+		m.outage.Duration = int64(start.Sub(outageStart).Seconds())
+		logger.Info("internet restored after second:", m.outage.Duration)
+		m.checkIp(now)
+		if m.outage.From != m.ip {
+			m.outage.To = m.ip
+		}
+		m.append(*m.outage)
+		m.outage = nil
+	} else if now.Sub(m.ipChecked) >= m.ipInterval {
+		m.checkIp(now)
+	}
+	return result
+}
+
+// checkIp looks up the public ip and records a change, the caller holds the lock
+func (m *Monitor) checkIp(now time.Time) {
+	if m.ipCheckUrl == "" {
+		return
+	}
+	resp, err := resty.New().SetTimeout(10 * time.Second).R().Get(m.ipCheckUrl)
+	if err != nil || resp.IsError() {
+		logger.Error("public ip lookup err:", err)
+		return
+	}
+	m.ipChecked = now
+	ip := strings.TrimSpace(resp.String())
+	if ip == "" || ip == m.ip {
+		return
+	}
+	if m.ip != "" {
+		logger.Info("public ip changed from:", m.ip, " to:", ip)
+		m.append(types.ConnectivityEvent{Type: EventIpChange, Start: now.Format("2006-01-02 15:04:05 -0700"), From: m.ip, To: ip})
+	} else {
+		m.append(types.ConnectivityEvent{Type: EventIpChange, Start: now.Format("2006-01-02 15:04:05 -0700"), To: ip})
+	}
+	m.ip = ip
+}
+
+func (m *Monitor) append(event types.ConnectivityEvent) {
+	if m.path == "" {
+		return
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		logger.Error("marshal connectivity event err:", err)
+		return
+	}
+	file, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logger.Error("open connectivity file err:", err)
+		return
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	if err != nil {
+		logger.Error("write connectivity event err:", err)
+	}
+}
+
+func (m *Monitor) events() []types.ConnectivityEvent {
+	events := []types.ConnectivityEvent{}
+	if m.path == "" {
+		return events
+	}
+	file, err := os.Open(m.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Error("open connectivity file err:", err)
+		}
+		return events
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event types.ConnectivityEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			logger.Error("parse connectivity event err:", err)
+			continue
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// Report returns the recorded events since the given time, zero means all
+func (m *Monitor) Report(since time.Time) types.ConnectivityReport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	report := types.ConnectivityReport{Online: m.outage == nil, PublicIp: m.ip, Events: []types.ConnectivityEvent{}}
+	if m.outage != nil {
+		outage := *m.outage
+		report.Outage = &outage
+	}
+	for _, event := range m.events() {
+		start, err := time.Parse("2006-01-02 15:04:05 -0700", event.Start)
+		if err == nil && start.Before(since) {
+			continue
+		}
+		switch event.Type {
+		case EventOutage:
+			report.Outages++
+			report.Downtime += event.Duration
+		case EventIpChange:
+			if event.From != "" {
+				report.IpChanges++
+			}
+		}
+		report.Events = append(report.Events, event)
+	}
+	return report
+}
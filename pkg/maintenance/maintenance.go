@@ -0,0 +1,236 @@
+package maintenance
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/schedule"
+	"elpsykongroo.com/monitor/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.New()
+
+type window struct {
+	types.Maintenance
+	start    time.Time
+	end      time.Time
+	cron     *schedule.Cron
+	duration time.Duration
+	targets  map[string]bool
+	config   bool
+}
+
+// Windows holds the maintenance windows of the config and the ones created by the api,
+// created windows are saved to path so they survive a restart
+type Windows struct {
+	mu      sync.RWMutex
+	path    string
+	windows []*window
+}
+
+func New(list []types.Maintenance, path string) *Windows {
+	w := &Windows{path: path}
+	for i, maintenance := range list {
+		if maintenance.Id == "" {
+			maintenance.Id = "config-" + strconv.Itoa(i)
+		}
+		parsed, err := parse(maintenance)
+		if err != nil {
+			logger.Warn("skip maintenance window:", maintenance.Name, " err:", err)
+			continue
+		}
+		parsed.config = true
+		w.windows = append(w.windows, parsed)
+	}
+	for _, maintenance := range w.load() {
+		parsed, err := parse(maintenance)
+		if err != nil {
+			logger.Warn("skip saved maintenance window:", maintenance.Id, " err:", err)
+			continue
+		}
+		w.windows = append(w.windows, parsed)
+	}
+	return w
+}
+
+func parse(maintenance types.Maintenance) (*window, error) {
+	w := &window{Maintenance: maintenance}
+	if maintenance.Cron != "" {
+		c, err := schedule.ParseCron(maintenance.Cron)
+		if err != nil {
+			return nil, err
+		}
+		duration, err := time.ParseDuration(maintenance.Duration)
+		if err != nil || duration <= 0 {
+			return nil, errors.New("recurring window needs a positive duration")
+		}
+		w.cron = c
+		w.duration = duration
+	} else {
+		start, err := parseTime(maintenance.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseTime(maintenance.End)
+		if err != nil {
+			return nil, err
+		}
+		if !end.After(start) {
+			return nil, errors.New("window end must be after start")
+		}
+		w.start = start
+		w.end = end
+	}
+	if len(maintenance.Targets) > 0 {
+		w.targets = make(map[string]bool)
+		for _, target := range maintenance.Targets {
+			w.targets[target] = true
+		}
+	}
+	return w, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	t, err := time.Parse("2006-01-02 15:04:05 -0700", value)
+	if err != nil {
+		return time.Parse(time.RFC3339, value)
+	}
+	return t, nil
+}
+
+func (w *window) active(target string, now time.Time) bool {
+	if w.targets != nil && !w.targets[target] {
+		return false
+	}
+	if w.cron == nil {
+		return !now.Before(w.start) && now.Before(w.end)
+	}
+	// the latest occurrence not older than duration, Next is strictly after its argument
+	occurrence := w.cron.Next(now.Add(-w.duration))
+	return !occurrence.IsZero() && !occurrence.After(now)
+}
+
+// Active returns the window covering the target at now, nil when there is none
+func (w *Windows) Active(target string, now time.Time) *types.Maintenance {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, window := range w.windows {
+		if window.active(target, now) {
+			maintenance := window.Maintenance
+			return &maintenance
+		}
+	}
+	return nil
+}
+
+// Apply tags a result inside a maintenance window, the probe outcome is kept in the detail
+func (w *Windows) Apply(result types.Result, now time.Time) types.Result {
+	maintenance := w.Active(result.Target, now)
+	if maintenance == nil {
+		return result
+	}
+	if result.Detail == nil {
+		result.Detail = &types.Detail{}
+	}
+	if result.Detail.ProbeOutcome == "" {
+		result.Detail.ProbeOutcome = result.Outcome
+	}
+	result.Detail.Maintenance = maintenance.Name
+	if result.Detail.Maintenance == "" {
+		result.Detail.Maintenance = maintenance.Id
+	}
+	result.Outcome = types.OutcomeMaintenance
+	return result
+}
+
+func (w *Windows) List() []types.Maintenance {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	list := make([]types.Maintenance, 0, len(w.windows))
+	for _, window := range w.windows {
+		list = append(list, window.Maintenance)
+	}
+	return list
+}
+
+// Add validates and saves a window created by the api
+func (w *Windows) Add(maintenance types.Maintenance) (types.Maintenance, error) {
+	id := make([]byte, 8)
+	_, err := rand.Read(id)
+	if err != nil {
+		return maintenance, err
+	}
+	maintenance.Id = hex.EncodeToString(id)
+	parsed, err := parse(maintenance)
+	if err != nil {
+		return maintenance, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.windows = append(w.windows, parsed)
+	w.save()
+	return maintenance, nil
+}
+
+// Delete removes a window created by the api, config windows are read only
+func (w *Windows) Delete(id string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i, window := range w.windows {
+		if window.Id == id && !window.config {
+			w.windows = append(w.windows[:i], w.windows[i+1:]...)
+			w.save()
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Windows) load() []types.Maintenance {
+	if w.path == "" {
+		return nil
+	}
+	content, err := os.ReadFile(w.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Error("read maintenance file err:", err)
+		}
+		return nil
+	}
+	var list []types.Maintenance
+	err = json.Unmarshal(content, &list)
+	if err != nil {
+		logger.Error("parse maintenance file err:", err)
+		return nil
+	}
+	return list
+}
+
+// save writes the created windows, the caller holds the lock
+func (w *Windows) save() {
+	if w.path == "" {
+		return
+	}
+	list := []types.Maintenance{}
+	for _, window := range w.windows {
+		if !window.config {
+			list = append(list, window.Maintenance)
+		}
+	}
+	content, err := json.Marshal(list)
+	if err != nil {
+		logger.Error("marshal maintenance err:", err)
+		return
+	}
+	err = os.WriteFile(w.path, content, 0644)
+	if err != nil {
+		logger.Error("write maintenance file err:", err)
+	}
+}
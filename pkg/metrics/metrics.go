@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/types"
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/disk"
+	"github.com/shirou/gopsutil/host"
+	"github.com/shirou/gopsutil/load"
+	"github.com/shirou/gopsutil/mem"
+	"github.com/shirou/gopsutil/net"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.New()
+
+// Collect samples the host, a failed collector only leaves its fields empty
+func Collect() types.HostMetrics {
+	sample := types.HostMetrics{
+		Timestamp: time.Now().Format("2006-01-02 15:04:05 -0700"),
+	}
+	percent, err := cpu.Percent(0, false)
+	if err != nil {
+		logger.Error("cpu percent err:", err)
+	} else if len(percent) > 0 {
+		sample.Cpu = percent[0]
+	}
+
+	avg, err := load.Avg()
+	if err != nil {
+		logger.Error("load avg err:", err)
+	} else {
+		sample.Load1, sample.Load5, sample.Load15 = avg.Load1, avg.Load5, avg.Load15
+	}
+
+	memory, err := mem.VirtualMemory()
+	if err != nil {
+		logger.Error("memory err:", err)
+	} else {
+		sample.Memory = types.Usage{Total: memory.Total, Used: memory.Used, UsedPercent: memory.UsedPercent}
+	}
+
+	swap, err := mem.SwapMemory()
+	if err != nil {
+		logger.Error("swap err:", err)
+	} else {
+		sample.Swap = types.Usage{Total: swap.Total, Used: swap.Used, UsedPercent: swap.UsedPercent}
+	}
+
+	partitions, err := disk.Partitions(false)
+	if err != nil {
+		logger.Error("disk partitions err:", err)
+	}
+	for _, partition := range partitions {
+		usage, err := disk.Usage(partition.Mountpoint)
+		if err != nil {
+			logger.Debug("disk usage err:", partition.Mountpoint, " ", err)
+			continue
+		}
+		sample.Disks = append(sample.Disks, types.DiskUsage{
+			Mount:  partition.Mountpoint,
+			Fstype: partition.Fstype,
+			Usage:  types.Usage{Total: usage.Total, Used: usage.Used, UsedPercent: usage.UsedPercent},
+		})
+	}
+
+	counters, err := net.IOCounters(true)
+	if err != nil {
+		logger.Error("net counters err:", err)
+	}
+	for _, counter := range counters {
+		sample.Net = append(sample.Net, types.NetCounter{
+			Name:        counter.Name,
+			BytesSent:   counter.BytesSent,
+			BytesRecv:   counter.BytesRecv,
+			PacketsSent: counter.PacketsSent,
+			PacketsRecv: counter.PacketsRecv,
+			Errin:       counter.Errin,
+			Errout:      counter.Errout,
+		})
+	}
+
+	// sensors are missing on most virtual machines, only warnings are returned then
+	temperatures, err := host.SensorsTemperatures()
+	if err != nil {
+		logger.Debug("temperature err:", err)
+	}
+	for _, temperature := range temperatures {
+		sample.Temperatures = append(sample.Temperatures, types.Temperature{
+			Sensor: temperature.SensorKey,
+			Value:  temperature.Temperature,
+		})
+	}
+	return sample
+}
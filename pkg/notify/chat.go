@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"errors"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"elpsykongroo.com/monitor/pkg/types"
+)
+
+func color(status string) int {
+	switch status {
+	case types.OutcomeUp:
+		return 0x2eb886
+	case types.OutcomeDegraded:
+		return 0xdaa038
+	default:
+		return 0xa30200
+	}
+}
+
+// priority maps the status to the top of scale, 5 for ntfy and 10 for gotify
+func priority(notifier types.Notifier, status string, scale int) int {
+	if notifier.Priority > 0 {
+		return notifier.Priority
+	}
+	switch status {
+	case types.OutcomeUp:
+		return scale * 3 / 10
+	case types.OutcomeDegraded:
+		return scale * 6 / 10
+	default:
+		return scale
+	}
+}
+
+// mentions are only added to down alerts
+func mentions(notifier types.Notifier, message Message) string {
+	if message.Alert.Status != types.OutcomeDown {
+		return ""
+	}
+	return strings.Join(notifier.Mentions, " ")
+}
+
+func baseUrl(notifier types.Notifier, fallback string) string {
+	if notifier.Url != "" {
+		return strings.TrimSuffix(notifier.Url, "/")
+	}
+	return fallback
+}
+
+// Telegram sends an html message with the bot api
+func Telegram(notifier types.Notifier, message Message) error {
+	text := "<b>" + html.EscapeString(message.Subject) + "</b>\n" + html.EscapeString(message.Text)
+	if m := mentions(notifier, message); m != "" {
+		text += "\n" + html.EscapeString(m)
+	}
+	return post(baseUrl(notifier, "https://api.telegram.org")+"/bot"+notifier.Token+"/sendMessage", notifier.Headers, "application/json", map[string]interface{}{
+		"chat_id":    notifier.ChatId,
+		"text":       text,
+		"parse_mode": "HTML",
+	})
+}
+
+// Slack posts a colored attachment to an incoming webhook
+func Slack(notifier types.Notifier, message Message) error {
+	return post(notifier.Url, notifier.Headers, "application/json", map[string]interface{}{
+		"text": strings.TrimSpace(mentions(notifier, message) + " " + message.Subject),
+		"attachments": []map[string]interface{}{{
+			"color":    "#" + strconv.FormatInt(int64(color(message.Alert.Status)), 16),
+			"title":    message.Subject,
+			"text":     message.Text,
+			"fallback": message.Text,
+		}},
+	})
+}
+
+// Discord posts a colored embed to a channel webhook
+func Discord(notifier types.Notifier, message Message) error {
+	return post(notifier.Url, notifier.Headers, "application/json", map[string]interface{}{
+		"content": mentions(notifier, message),
+		"embeds": []map[string]interface{}{{
+			"title":       message.Subject,
+			"description": message.Text,
+			"color":       color(message.Alert.Status),
+		}},
+	})
+}
+
+// Ntfy publishes a plain message to the topic, the priority and tags are set by header
+func Ntfy(notifier types.Notifier, message Message) error {
+	headers := map[string]string{
+		"Title":    message.Subject,
+		"Priority": strconv.Itoa(priority(notifier, message.Alert.Status, 5)),
+		"Tags":     "rotating_light",
+	}
+	switch message.Alert.Status {
+	case types.OutcomeUp:
+		headers["Tags"] = "white_check_mark"
+	case types.OutcomeDegraded:
+		headers["Tags"] = "warning"
+	}
+	if notifier.Token != "" {
+		headers["Authorization"] = "Bearer " + notifier.Token
+	}
+	for key, value := range notifier.Headers {
+		headers[key] = value
+	}
+	return post(baseUrl(notifier, "https://ntfy.sh")+"/"+url.PathEscape(notifier.Topic), headers, "text/plain; charset=utf-8", message.Text)
+}
+
+// Gotify creates an application message with the app token
+func Gotify(notifier types.Notifier, message Message) error {
+	if notifier.Url == "" {
+		return errors.New("gotify url is required")
+	}
+	return post(baseUrl(notifier, "")+"/message?token="+url.QueryEscape(notifier.Token), notifier.Headers, "application/json", map[string]interface{}{
+		"title":    message.Subject,
+		"message":  message.Text,
+		"priority": priority(notifier, message.Alert.Status, 10),
+	})
+}
@@ -0,0 +1,150 @@
+package notify
+
+import (
+	"testing"
+
+	"elpsykongroo.com/monitor/pkg/types"
+)
+
+func message(t *testing.T, status string) Message {
+	t.Helper()
+	message, err := Render(types.Templates{}, types.Alert{Name: "monitor", DeviceId: "device", Target: "web", Status: status, Error: "a < b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return message
+}
+
+func TestTelegram(t *testing.T) {
+	server, requests, _ := receiver(t, 0)
+	notifier := types.Notifier{Url: server.URL, Token: "123:abc", ChatId: "-42", Mentions: []string{"@ops"}}
+	if err := Telegram(notifier, message(t, types.OutcomeDown)); err != nil {
+		t.Fatal(err)
+	}
+	last := next(t, requests)
+	if last.path != "/bot123:abc/sendMessage" {
+		t.Errorf("got path %q", last.path)
+	}
+	payload := decode(t, last.body)
+	if payload["chat_id"] != "-42" || payload["parse_mode"] != "HTML" {
+		t.Errorf("got %v", payload)
+	}
+	if text := payload["text"].(string); text != "<b>[monitor] web is down</b>\n"+"target: web\nstatus: down\ndevice: device\ntime: \nerror: a &lt; b\n\n@ops" {
+		t.Errorf("got text %q", text)
+	}
+}
+
+func TestSlack(t *testing.T) {
+	server, requests, _ := receiver(t, 0)
+	notifier := types.Notifier{Url: server.URL + "/services/hook", Mentions: []string{"<!channel>"}}
+	tests := []struct {
+		status string
+		color  string
+		text   string
+	}{
+		{types.OutcomeDown, "#a30200", "<!channel> [monitor] web is down"},
+		{types.OutcomeDegraded, "#daa038", "[monitor] web is degraded"},
+		{types.OutcomeUp, "#2eb886", "[monitor] web is up"},
+	}
+	for _, test := range tests {
+		if err := Slack(notifier, message(t, test.status)); err != nil {
+			t.Fatal(err)
+		}
+		last := next(t, requests)
+		if last.path != "/services/hook" {
+			t.Errorf("got path %q", last.path)
+		}
+		payload := decode(t, last.body)
+		if payload["text"] != test.text {
+			t.Errorf("%s: got text %q, want %q", test.status, payload["text"], test.text)
+		}
+		attachment := payload["attachments"].([]interface{})[0].(map[string]interface{})
+		if attachment["color"] != test.color || attachment["title"] != "[monitor] web is "+test.status {
+			t.Errorf("%s: got attachment %v", test.status, attachment)
+		}
+	}
+}
+
+func TestDiscord(t *testing.T) {
+	server, requests, _ := receiver(t, 0)
+	notifier := types.Notifier{Url: server.URL + "/api/webhooks/1/token", Mentions: []string{"<@&99>"}}
+	tests := []struct {
+		status  string
+		color   float64
+		content string
+	}{
+		{types.OutcomeDown, 0xa30200, "<@&99>"},
+		{types.OutcomeDegraded, 0xdaa038, ""},
+		{types.OutcomeUp, 0x2eb886, ""},
+	}
+	for _, test := range tests {
+		if err := Discord(notifier, message(t, test.status)); err != nil {
+			t.Fatal(err)
+		}
+		last := next(t, requests)
+		if last.path != "/api/webhooks/1/token" {
+			t.Errorf("got path %q", last.path)
+		}
+		payload := decode(t, last.body)
+		if payload["content"] != test.content {
+			t.Errorf("%s: got content %q, want %q", test.status, payload["content"], test.content)
+		}
+		embed := payload["embeds"].([]interface{})[0].(map[string]interface{})
+		if embed["color"] != test.color || embed["title"] != "[monitor] web is "+test.status {
+			t.Errorf("%s: got embed %v", test.status, embed)
+		}
+	}
+}
+
+func TestNtfy(t *testing.T) {
+	server, requests, _ := receiver(t, 0)
+	tests := []struct {
+		notifier types.Notifier
+		status   string
+		priority string
+		tags     string
+	}{
+		{types.Notifier{Url: server.URL, Topic: "alerts", Token: "tk"}, types.OutcomeDown, "5", "rotating_light"},
+		{types.Notifier{Url: server.URL, Topic: "alerts", Token: "tk"}, types.OutcomeDegraded, "3", "warning"},
+		{types.Notifier{Url: server.URL, Topic: "alerts", Token: "tk"}, types.OutcomeUp, "1", "white_check_mark"},
+		{types.Notifier{Url: server.URL, Topic: "alerts", Token: "tk", Priority: 4}, types.OutcomeUp, "4", "white_check_mark"},
+	}
+	for _, test := range tests {
+		msg := message(t, test.status)
+		if err := Ntfy(test.notifier, msg); err != nil {
+			t.Fatal(err)
+		}
+		last := next(t, requests)
+		if last.path != "/alerts" {
+			t.Errorf("got path %q", last.path)
+		}
+		if last.header.Get("Title") != "[monitor] web is "+test.status || last.header.Get("Priority") != test.priority || last.header.Get("Tags") != test.tags {
+			t.Errorf("%s: got title %q priority %q tags %q", test.status, last.header.Get("Title"), last.header.Get("Priority"), last.header.Get("Tags"))
+		}
+		if last.header.Get("Authorization") != "Bearer tk" {
+			t.Errorf("got authorization %q", last.header.Get("Authorization"))
+		}
+		if string(last.body) != msg.Text {
+			t.Errorf("got body %q, want %q", last.body, msg.Text)
+		}
+	}
+}
+
+func TestGotify(t *testing.T) {
+	server, requests, _ := receiver(t, 0)
+	notifier := types.Notifier{Url: server.URL + "/", Token: "app&token"}
+	if err := Gotify(notifier, message(t, types.OutcomeDown)); err != nil {
+		t.Fatal(err)
+	}
+	last := next(t, requests)
+	if last.path != "/message" || last.query != "token=app%26token" {
+		t.Errorf("got path %q query %q", last.path, last.query)
+	}
+	payload := decode(t, last.body)
+	if payload["title"] != "[monitor] web is down" || payload["priority"] != float64(10) {
+		t.Errorf("got %v", payload)
+	}
+	if err := Gotify(types.Notifier{Token: "app"}, message(t, types.OutcomeDown)); err == nil {
+		t.Error("gotify without url succeeded")
+	}
+}
@@ -0,0 +1,151 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/check"
+	"elpsykongroo.com/monitor/pkg/types"
+)
+
+const (
+	SecurityPlain    = "plain"
+	SecurityStartTLS = "starttls"
+	SecurityTLS      = "tls"
+)
+
+// Email sends the alert to every recipient as a multipart message with text and html body,
+// the recipients of the target replace the to list of the notifier
+func Email(notifier types.Notifier, message Message) error {
+	options := notifier.Smtp
+	if len(message.Alert.Recipients) > 0 {
+		options.To = message.Alert.Recipients
+	}
+	if options.Host == "" || options.From == "" || len(options.To) == 0 {
+		return errors.New("smtp host, from and to are required")
+	}
+	mail, err := emailMessage(options, message.Subject, message.Text, message.Html)
+	if err != nil {
+		return err
+	}
+	return sendMail(options, mail)
+}
+
+func emailMessage(options types.Smtp, subject string, text string, html string) ([]byte, error) {
+	random := make([]byte, 12)
+	_, err := rand.Read(random)
+	if err != nil {
+		return nil, err
+	}
+	boundary := hex.EncodeToString(random)
+	var b bytes.Buffer
+	b.WriteString("From: " + options.From + "\r\n")
+	b.WriteString("To: " + strings.Join(options.To, ", ") + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: multipart/alternative; boundary=" + boundary + "\r\n\r\n")
+	for _, part := range [][2]string{{"text/plain", text}, {"text/html", html}} {
+		b.WriteString("--" + boundary + "\r\n")
+		b.WriteString("Content-Type: " + part[0] + "; charset=utf-8\r\n")
+		b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(part[1], "\r\n", "\n"), "\n", "\r\n"))
+		b.WriteString("\r\n")
+	}
+	b.WriteString("--" + boundary + "--\r\n")
+	return b.Bytes(), nil
+}
+
+// smtpAddr returns the security and address of the server, the port defaults to 25, 587 or 465
+func smtpAddr(options types.Smtp) (string, string) {
+	security := options.Security
+	if security == "" {
+		security = SecurityStartTLS
+	}
+	port := options.Port
+	if port == 0 {
+		switch security {
+		case SecurityPlain:
+			port = 25
+		case SecurityTLS:
+			port = 465
+		default:
+			port = 587
+		}
+	}
+	return security, net.JoinHostPort(options.Host, strconv.Itoa(port))
+}
+
+// sendMail dials the server with the configured security
+func sendMail(options types.Smtp, message []byte) error {
+	security, addr := smtpAddr(options)
+	tlsConfig := check.TlsConfig(options.Tls)
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = options.Host
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	var err error
+	switch security {
+	case SecurityTLS:
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	case SecurityPlain, SecurityStartTLS:
+		conn, err = dialer.Dial("tcp", addr)
+	default:
+		return errors.New("unknown smtp security: " + security)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+	client, err := smtp.NewClient(conn, options.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if security == SecurityStartTLS {
+		err = client.StartTLS(tlsConfig)
+		if err != nil {
+			return err
+		}
+	}
+	if options.Username != "" {
+		err = client.Auth(smtp.PlainAuth("", options.Username, options.Password, options.Host))
+		if err != nil {
+			return err
+		}
+	}
+	err = client.Mail(options.From)
+	if err != nil {
+		return err
+	}
+	for _, to := range options.To {
+		err = client.Rcpt(to)
+		if err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(message)
+	if err != nil {
+		return err
+	}
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,296 @@
+package notify
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/types"
+)
+
+// smtpSession is what the fake server received in one connection
+type smtpSession struct {
+	tls  bool
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTP is an in-process smtp server speaking security plain, starttls or tls,
+// it accepts AUTH PLAIN and passes every session to sessions
+func fakeSMTP(t *testing.T, security string, certificate tls.Certificate) (string, int, chan smtpSession) {
+	t.Helper()
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{certificate}}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if security == SecurityTLS {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	t.Cleanup(func() { listener.Close() })
+	sessions := make(chan smtpSession, 1)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, security, tlsConfig, sessions)
+		}
+	}()
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, sessions
+}
+
+func serveSMTP(conn net.Conn, security string, tlsConfig *tls.Config, sessions chan smtpSession) {
+	defer conn.Close()
+	session := smtpSession{tls: security == SecurityTLS}
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 fake smtp")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-fake")
+			if security == SecurityStartTLS && !session.tls {
+				reply("250-STARTTLS")
+			}
+			reply("250 AUTH PLAIN")
+		case "STARTTLS":
+			reply("220 go ahead")
+			tlsConn := tls.Server(conn, tlsConfig)
+			if tlsConn.Handshake() != nil {
+				return
+			}
+			conn = tlsConn
+			reader = bufio.NewReader(conn)
+			session.tls = true
+		case "AUTH":
+			_, encoded, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(encoded)
+			session.auth = string(decoded)
+			reply("235 authenticated")
+		case "MAIL":
+			session.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			if i := strings.Index(session.from, ">"); i >= 0 {
+				session.from = session.from[:i]
+			}
+			reply("250 ok")
+		case "RCPT":
+			session.to = append(session.to, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			reply("250 ok")
+		case "DATA":
+			reply("354 end with .")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			session.data = data.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			sessions <- session
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+// selfSigned returns a certificate for 127.0.0.1 and the pem file of it for the notifier ca file
+func selfSigned(t *testing.T) (tls.Certificate, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fake smtp"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	err = os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, caFile
+}
+
+func TestEmail(t *testing.T) {
+	certificate, caFile := selfSigned(t)
+	alert := types.Alert{Name: "monitor", DeviceId: "device", Target: "web", Status: types.OutcomeDown, PreviousStatus: types.OutcomeUp, Error: "<refused>"}
+	message, err := Render(types.Templates{}, alert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, security := range []string{SecurityPlain, SecurityStartTLS, SecurityTLS} {
+		t.Run(security, func(t *testing.T) {
+			host, port, sessions := fakeSMTP(t, security, certificate)
+			notifier := types.Notifier{Type: types.NotifyEmail, Smtp: types.Smtp{
+				Host:     host,
+				Port:     port,
+				Security: security,
+				Tls:      types.TlsOptions{CaFile: caFile},
+				Username: "user",
+				Password: "secret",
+				From:     "monitor@example.com",
+				To:       []string{"a@example.com", "b@example.com"},
+			}}
+			if err := Email(notifier, message); err != nil {
+				t.Fatal(err)
+			}
+			session := <-sessions
+			if session.tls != (security != SecurityPlain) {
+				t.Errorf("got tls %v", session.tls)
+			}
+			if session.auth != "\x00user\x00secret" {
+				t.Errorf("got auth %q", session.auth)
+			}
+			if session.from != "monitor@example.com" || strings.Join(session.to, ",") != "a@example.com,b@example.com" {
+				t.Errorf("got from %q to %v", session.from, session.to)
+			}
+			checkMultipart(t, session.data, message)
+		})
+	}
+}
+
+func checkMultipart(t *testing.T, data string, message Message) {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "[monitor] web is down" {
+		t.Errorf("got subject %q, err %v", subject, err)
+	}
+	if msg.Header.Get("To") != "a@example.com, b@example.com" {
+		t.Errorf("got to header %q", msg.Header.Get("To"))
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("got content type %q, err %v", mediaType, err)
+	}
+	parts := map[string]string{}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(part)
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[contentType] = strings.ReplaceAll(string(body), "\r\n", "\n")
+	}
+	if strings.TrimSpace(parts["text/plain"]) != strings.TrimSpace(message.Text) {
+		t.Errorf("got text part %q, want %q", parts["text/plain"], message.Text)
+	}
+	if !strings.Contains(parts["text/plain"], "error: <refused>") {
+		t.Errorf("text part is escaped: %q", parts["text/plain"])
+	}
+	if !strings.Contains(parts["text/html"], "<td>&lt;refused&gt;</td>") {
+		t.Errorf("got html part %q", parts["text/html"])
+	}
+}
+
+func TestEmailRequiresRecipients(t *testing.T) {
+	err := Email(types.Notifier{Smtp: types.Smtp{Host: "127.0.0.1", Port: 1, From: "monitor@example.com"}}, Message{})
+	if err == nil {
+		t.Error("email without recipients succeeded")
+	}
+}
+
+func TestSmtpAddr(t *testing.T) {
+	tests := []struct {
+		options  types.Smtp
+		security string
+		addr     string
+	}{
+		{types.Smtp{Host: "mail.example.com"}, SecurityStartTLS, "mail.example.com:587"},
+		{types.Smtp{Host: "mail.example.com", Security: SecurityPlain}, SecurityPlain, "mail.example.com:25"},
+		{types.Smtp{Host: "mail.example.com", Security: SecurityStartTLS}, SecurityStartTLS, "mail.example.com:587"},
+		{types.Smtp{Host: "mail.example.com", Security: SecurityTLS}, SecurityTLS, "mail.example.com:465"},
+		{types.Smtp{Host: "::1", Security: SecurityTLS, Port: 2465}, SecurityTLS, "[::1]:2465"},
+	}
+	for _, test := range tests {
+		security, addr := smtpAddr(test.options)
+		if security != test.security || addr != test.addr {
+			t.Errorf("%+v: got %s %s, want %s %s", test.options, security, addr, test.security, test.addr)
+		}
+	}
+}
+
+func TestEmailTargetRecipients(t *testing.T) {
+	certificate, _ := selfSigned(t)
+	host, port, sessions := fakeSMTP(t, SecurityPlain, certificate)
+	a := New(types.Config{Name: "monitor", Notifiers: []types.Notifier{{Name: "mail", Type: types.NotifyEmail, Smtp: types.Smtp{
+		Host:     host,
+		Port:     port,
+		Security: SecurityPlain,
+		From:     "monitor@example.com",
+		To:       []string{"ops@example.com"},
+	}}}}, "device", map[string]Route{"billing": {Recipients: []string{"billing@example.com", "finance@example.com"}}})
+	for _, target := range []string{"billing", "web"} {
+		a.Observe(types.Result{Target: target, Outcome: types.OutcomeUp})
+		a.Observe(types.Result{Target: target, Outcome: types.OutcomeDown})
+	}
+	// one worker per notifier delivers in order
+	for _, want := range []string{"billing@example.com, finance@example.com", "ops@example.com"} {
+		select {
+		case session := <-sessions:
+			msg, err := mail.ReadMessage(strings.NewReader(session.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(session.to, ", "); got != want || msg.Header.Get("To") != want {
+				t.Errorf("got recipients %s to header %s, want %s", got, msg.Header.Get("To"), want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no email received")
+		}
+	}
+}
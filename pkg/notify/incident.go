@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/types"
+)
+
+// dedupKey is the same for every alert of a target on this device, so the recovery
+// resolves the incident opened by the failure
+func dedupKey(alert types.Alert) string {
+	return "monitor:" + alert.DeviceId + ":" + alert.Target
+}
+
+// details are string values as opsgenie requires
+func details(alert types.Alert) map[string]string {
+	return map[string]string{
+		"target":         alert.Target,
+		"status":         alert.Status,
+		"previousStatus": alert.PreviousStatus,
+		"deviceId":       alert.DeviceId,
+		"timestamp":      alert.Timestamp,
+		"duration":       strconv.FormatInt(alert.Duration, 10),
+		"error":          alert.Error,
+	}
+}
+
+// PagerDuty triggers an event with the routing key in token, an up alert resolves it
+func PagerDuty(notifier types.Notifier, message Message) error {
+	alert := message.Alert
+	if notifier.Token == "" {
+		return errors.New("pagerduty routing key is required")
+	}
+	event := map[string]interface{}{
+		"routing_key": notifier.Token,
+		"dedup_key":   dedupKey(alert),
+	}
+	if alert.Status == types.OutcomeUp {
+		event["event_action"] = "resolve"
+	} else {
+		severity := "critical"
+		if alert.Status == types.OutcomeDegraded {
+			severity = "warning"
+		}
+		payload := map[string]interface{}{
+			"summary":        message.Subject,
+			"source":         alert.Name + "_" + alert.DeviceId,
+			"severity":       severity,
+			"component":      alert.Target,
+			"custom_details": details(alert),
+		}
+		if t, err := time.Parse("2006-01-02 15:04:05 -0700", alert.Timestamp); err == nil {
+			payload["timestamp"] = t.Format(time.RFC3339)
+		}
+		event["event_action"] = "trigger"
+		event["payload"] = payload
+	}
+	return post(baseUrl(notifier, "https://events.pagerduty.com")+"/v2/enqueue", notifier.Headers, "application/json", event)
+}
+
+// Opsgenie creates an alert with the api key in token, an up alert closes it by alias
+func Opsgenie(notifier types.Notifier, message Message) error {
+	alert := message.Alert
+	if notifier.Token == "" {
+		return errors.New("opsgenie api key is required")
+	}
+	headers := map[string]string{"Authorization": "GenieKey " + notifier.Token}
+	for key, value := range notifier.Headers {
+		headers[key] = value
+	}
+	base := baseUrl(notifier, "https://api.opsgenie.com") + "/v2/alerts"
+	source := alert.Name + "_" + alert.DeviceId
+	if alert.Status == types.OutcomeUp {
+		return post(base+"/"+url.PathEscape(dedupKey(alert))+"/close?identifierType=alias", headers, "application/json", map[string]interface{}{
+			"source": source,
+			"note":   message.Text,
+		})
+	}
+	priority := notifier.Priority
+	if priority <= 0 || priority > 5 {
+		priority = 2
+		if alert.Status == types.OutcomeDegraded {
+			priority = 3
+		}
+	}
+	return post(base, headers, "application/json", map[string]interface{}{
+		"message":     message.Subject,
+		"alias":       dedupKey(alert),
+		"description": message.Text,
+		"source":      source,
+		"entity":      alert.Target,
+		"priority":    "P" + strconv.Itoa(priority),
+		"details":     details(alert),
+	})
+}
@@ -0,0 +1,213 @@
+package notify
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/types"
+	"elpsykongroo.com/monitor/pkg/vault"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.New()
+
+// recentResults is the number of results kept per target for templates
+const recentResults = 10
+
+type state struct {
+	outcome string
+	since   time.Time
+}
+
+// worker delivers the alerts of one notifier in order
+type worker struct {
+	notifier types.Notifier
+	queue    chan types.Alert
+}
+
+// Route selects the notifiers of a target, Recipients replace the to list of its email notifiers
+type Route struct {
+	Notify     []string
+	Recipients []string
+}
+
+// Alerter watches the outcome of every target and sends an alert on each transition,
+// every notifier has its own worker so a slow or dead one never holds back the others or the checks
+type Alerter struct {
+	mu       sync.Mutex
+	name     string
+	deviceId string
+	workers  []*worker
+	routes   map[string]Route
+	states   map[string]state
+	history  map[string][]types.Result
+}
+
+// New starts the delivery workers, routes maps a target to its notifiers and recipients
+func New(config types.Config, deviceId string, routes map[string]Route) *Alerter {
+	a := &Alerter{
+		name:     config.Name,
+		deviceId: deviceId,
+		routes:   routes,
+		states:   make(map[string]state),
+		history:  make(map[string][]types.Result),
+	}
+	for _, notifier := range resolveAuth(config) {
+		w := &worker{notifier: notifier, queue: make(chan types.Alert, 100)}
+		a.workers = append(a.workers, w)
+		go a.deliver(w)
+	}
+	return a
+}
+
+// Observe compares the result with the last outcome of its target, maintenance and
+// unreachable results neither alert nor change the tracked outcome
+func (a *Alerter) Observe(result types.Result) {
+	now := time.Now()
+	a.mu.Lock()
+	history := append(a.history[result.Target], result)
+	if len(history) > recentResults {
+		history = history[len(history)-recentResults:]
+	}
+	a.history[result.Target] = history
+	if result.Outcome == "" || result.Outcome == types.OutcomeMaintenance || result.Outcome == types.OutcomeUnreachable {
+		a.mu.Unlock()
+		return
+	}
+	previous, known := a.states[result.Target]
+	if known && previous.outcome == result.Outcome {
+		a.mu.Unlock()
+		return
+	}
+	a.states[result.Target] = state{outcome: result.Outcome, since: now}
+	a.mu.Unlock()
+	// the first result after a start only goes to incident notifiers, it resolves an incident
+	// opened before a restart or repeats one that the same dedup key makes harmless
+	startup := !known
+	alert := types.Alert{
+		Name:           a.name,
+		DeviceId:       a.deviceId,
+		Target:         result.Target,
+		Status:         result.Outcome,
+		PreviousStatus: previous.outcome,
+		Timestamp:      result.Timestamp,
+		Results:        append([]types.Result(nil), history...),
+		Recipients:     a.routes[result.Target].Recipients,
+	}
+	if known {
+		alert.Duration = int64(now.Sub(previous.since).Seconds())
+	}
+	if result.Detail != nil {
+		alert.Error = result.Detail.Error
+	}
+	for _, w := range a.workers {
+		if !a.routed(alert.Target, w.notifier.Name) || startup && !incident(w.notifier.Type) {
+			continue
+		}
+		select {
+		case w.queue <- alert:
+		default:
+			logger.Error("alert queue full, drop alert, notifier:", w.notifier.Name, " target:", alert.Target, " status:", alert.Status)
+		}
+	}
+}
+
+// resolveAuth fills the smtp credentials from vault when a vault path is configured
+func resolveAuth(config types.Config) []types.Notifier {
+	notifiers := append([]types.Notifier(nil), config.Notifiers...)
+	for i, notifier := range notifiers {
+		if notifier.Smtp.VaultPath == "" {
+			continue
+		}
+		secret := vault.ReadSecret(config, notifier.Smtp.VaultPath)
+		if secret == nil {
+			logger.Error("resolve smtp auth from vault failed, notifier:", notifier.Name)
+			continue
+		}
+		if secret["username"] != "" {
+			notifiers[i].Smtp.Username = secret["username"]
+		}
+		if secret["password"] != "" {
+			notifiers[i].Smtp.Password = secret["password"]
+		}
+	}
+	return notifiers
+}
+
+func (a *Alerter) deliver(w *worker) {
+	for alert := range w.queue {
+		err := a.send(w.notifier, alert)
+		if err != nil {
+			logger.Error("send alert err, notifier:", w.notifier.Name, " target:", alert.Target, " err:", err)
+		}
+	}
+}
+
+// incident notifiers resolve by dedup key instead of posting a message
+func incident(notifierType string) bool {
+	return notifierType == types.NotifyPagerDuty || notifierType == types.NotifyOpsgenie
+}
+
+func (a *Alerter) routed(target string, notifier string) bool {
+	names := a.routes[target].Notify
+	if len(names) == 0 {
+		return true
+	}
+	for _, name := range names {
+		if name == notifier {
+			return true
+		}
+	}
+	return false
+}
+
+// send retries a failed delivery with the delay doubled each time
+func (a *Alerter) send(notifier types.Notifier, alert types.Alert) error {
+	retries := 3
+	if notifier.Retries != nil {
+		retries = *notifier.Retries
+	}
+	delay := time.Duration(notifier.RetryDelay) * time.Millisecond
+	if delay <= 0 {
+		delay = time.Second
+	}
+	err := Send(notifier, alert)
+	for attempt := 1; attempt <= retries && err != nil; attempt++ {
+		logger.Info("retry alert, notifier:", notifier.Name, " attempt:", attempt, " err:", err)
+		time.Sleep(delay)
+		delay *= 2
+		err = Send(notifier, alert)
+	}
+	return err
+}
+
+// Send renders the alert with the notifier templates and delivers it once
+func Send(notifier types.Notifier, alert types.Alert) error {
+	message, err := Render(notifier.Templates, alert)
+	if err != nil {
+		return err
+	}
+	switch notifier.Type {
+	case types.NotifyWebhook, "":
+		return Webhook(notifier, message)
+	case types.NotifyEmail:
+		return Email(notifier, message)
+	case types.NotifyTelegram:
+		return Telegram(notifier, message)
+	case types.NotifySlack:
+		return Slack(notifier, message)
+	case types.NotifyDiscord:
+		return Discord(notifier, message)
+	case types.NotifyNtfy:
+		return Ntfy(notifier, message)
+	case types.NotifyGotify:
+		return Gotify(notifier, message)
+	case types.NotifyPagerDuty:
+		return PagerDuty(notifier, message)
+	case types.NotifyOpsgenie:
+		return Opsgenie(notifier, message)
+	default:
+		return errors.New("unknown notifier type: " + notifier.Type)
+	}
+}
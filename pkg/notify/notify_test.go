@@ -0,0 +1,223 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/types"
+)
+
+// request is what a notifier sent to the receiver
+type request struct {
+	method string
+	path   string
+	query  string
+	header http.Header
+	body   []byte
+}
+
+// receiver is a local endpoint for every notifier, it answers 502 to the first failures requests
+func receiver(t *testing.T, failures int32) (*httptest.Server, chan request, *int32) {
+	t.Helper()
+	requests := make(chan request, 10)
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) <= failures {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{method: r.Method, path: r.URL.Path, query: r.URL.RawQuery, header: r.Header, body: body}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, requests, &count
+}
+
+// next waits for the next request to the receiver
+func next(t *testing.T, requests chan request) request {
+	t.Helper()
+	select {
+	case r := <-requests:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("no request received")
+		return request{}
+	}
+}
+
+// receive waits for the next request and decodes its json body
+func receive(t *testing.T, requests chan request) map[string]interface{} {
+	t.Helper()
+	r := next(t, requests)
+	if r.method != http.MethodPost || r.header.Get("Content-Type") != "application/json" {
+		t.Errorf("got %s with content type %q", r.method, r.header.Get("Content-Type"))
+	}
+	return decode(t, r.body)
+}
+
+func decode(t *testing.T, body []byte) map[string]interface{} {
+	t.Helper()
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("decode %q: %v", body, err)
+	}
+	return payload
+}
+
+func TestObserveTransitions(t *testing.T) {
+	server, alerts, _ := receiver(t, 0)
+	config := types.Config{Name: "monitor", Notifiers: []types.Notifier{{Name: "hook", Type: types.NotifyWebhook, Url: server.URL}}}
+	a := New(config, "device", nil)
+
+	a.Observe(types.Result{Timestamp: "2024-01-01 00:00:00 +0000", Target: "web", Outcome: types.OutcomeUp})
+	a.Observe(types.Result{Timestamp: "2024-01-01 00:01:00 +0000", Target: "web", Outcome: types.OutcomeDown, Detail: &types.Detail{Error: "connection refused"}})
+	down := receive(t, alerts)
+	time.Sleep(1100 * time.Millisecond)
+	a.Observe(types.Result{Timestamp: "2024-01-01 00:02:00 +0000", Target: "web", Outcome: types.OutcomeDown})
+	a.Observe(types.Result{Timestamp: "2024-01-01 00:03:00 +0000", Target: "web", Outcome: types.OutcomeUp})
+	up := receive(t, alerts)
+	a.Observe(types.Result{Timestamp: "2024-01-01 00:04:00 +0000", Target: "web", Outcome: types.OutcomeDegraded})
+	degraded := receive(t, alerts)
+
+	tests := []struct {
+		alert     map[string]interface{}
+		status    string
+		previous  string
+		timestamp string
+	}{
+		{down, types.OutcomeDown, types.OutcomeUp, "2024-01-01 00:01:00 +0000"},
+		{up, types.OutcomeUp, types.OutcomeDown, "2024-01-01 00:03:00 +0000"},
+		{degraded, types.OutcomeDegraded, types.OutcomeUp, "2024-01-01 00:04:00 +0000"},
+	}
+	for _, test := range tests {
+		if test.alert["target"] != "web" || test.alert["deviceId"] != "device" || test.alert["name"] != "monitor" {
+			t.Errorf("got target %v device %v name %v", test.alert["target"], test.alert["deviceId"], test.alert["name"])
+		}
+		if test.alert["status"] != test.status || test.alert["previousStatus"] != test.previous || test.alert["timestamp"] != test.timestamp {
+			t.Errorf("got %v after %v at %v, want %s after %s at %s", test.alert["status"], test.alert["previousStatus"], test.alert["timestamp"], test.status, test.previous, test.timestamp)
+		}
+		if _, ok := test.alert["duration"].(float64); !ok {
+			t.Errorf("%s alert has no duration", test.status)
+		}
+	}
+	if down["error"] != "connection refused" {
+		t.Errorf("got error %v", down["error"])
+	}
+	if duration := up["duration"].(float64); duration < 1 {
+		t.Errorf("recovery duration %v, want the outage of at least 1 second", duration)
+	}
+	select {
+	case alert := <-alerts:
+		t.Errorf("unexpected alert %s", alert.body)
+	default:
+	}
+}
+
+func TestObserveSkipsMaintenanceAndUnreachable(t *testing.T) {
+	server, alerts, _ := receiver(t, 0)
+	a := New(types.Config{Notifiers: []types.Notifier{{Name: "hook", Url: server.URL}}}, "device", nil)
+	a.Observe(types.Result{Target: "web", Outcome: types.OutcomeUp})
+	a.Observe(types.Result{Target: "web", Outcome: types.OutcomeMaintenance})
+	a.Observe(types.Result{Target: "web", Outcome: types.OutcomeUnreachable})
+	a.Observe(types.Result{Target: "web", Outcome: types.OutcomeUp})
+	select {
+	case alert := <-alerts:
+		t.Errorf("unexpected alert %s", alert.body)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestSendRetries(t *testing.T) {
+	server, alerts, requests := receiver(t, 2)
+	retries := 2
+	a := &Alerter{}
+	notifier := types.Notifier{Name: "hook", Url: server.URL, Retries: &retries, RetryDelay: 10}
+	err := a.send(notifier, types.Alert{Target: "web", Status: types.OutcomeDown})
+	if err != nil {
+		t.Fatalf("send after retries: %v", err)
+	}
+	if got := atomic.LoadInt32(requests); got != 3 {
+		t.Errorf("got %d requests, want 3", got)
+	}
+	if alert := receive(t, alerts); alert["status"] != types.OutcomeDown {
+		t.Errorf("got %v", alert)
+	}
+
+	server, _, requests = receiver(t, 100)
+	notifier.Url = server.URL
+	err = a.send(notifier, types.Alert{Target: "web", Status: types.OutcomeDown})
+	if err == nil {
+		t.Error("send to a failing receiver succeeded")
+	}
+	if got := atomic.LoadInt32(requests); got != 3 {
+		t.Errorf("got %d requests, want 1 and 2 retries", got)
+	}
+}
+
+func TestDeadNotifierDoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	dead := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer dead.Close()
+	defer close(release)
+	server, alerts, _ := receiver(t, 0)
+	a := New(types.Config{Notifiers: []types.Notifier{
+		{Name: "dead", Url: dead.URL},
+		{Name: "hook", Url: server.URL},
+	}}, "device", nil)
+	a.Observe(types.Result{Target: "web", Outcome: types.OutcomeUp})
+	a.Observe(types.Result{Target: "web", Outcome: types.OutcomeDown})
+	select {
+	case <-alerts:
+	case <-time.After(2 * time.Second):
+		t.Fatal("alert held back by a dead notifier")
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	server, alerts, _ := receiver(t, 0)
+	other, otherAlerts, _ := receiver(t, 0)
+	a := New(types.Config{Notifiers: []types.Notifier{
+		{Name: "hook", Url: server.URL},
+		{Name: "other", Url: other.URL},
+	}}, "device", map[string]Route{"web": {Notify: []string{"other"}}})
+	a.Observe(types.Result{Target: "web", Outcome: types.OutcomeUp})
+	a.Observe(types.Result{Target: "web", Outcome: types.OutcomeDown})
+	receive(t, otherAlerts)
+	select {
+	case alert := <-alerts:
+		t.Errorf("alert sent to a notifier not in the route: %s", alert.body)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestStartupResolvesIncidents(t *testing.T) {
+	hook, hookAlerts, _ := receiver(t, 0)
+	pagerduty, events, _ := receiver(t, 0)
+	a := New(types.Config{Notifiers: []types.Notifier{
+		{Name: "hook", Url: hook.URL},
+		{Name: "pagerduty", Type: types.NotifyPagerDuty, Url: pagerduty.URL, Token: "key"},
+	}}, "device", nil)
+	a.Observe(types.Result{Target: "web", Outcome: types.OutcomeUp})
+	event := receive(t, events)
+	if event["event_action"] != "resolve" || event["dedup_key"] != "monitor:device:web" {
+		t.Errorf("got %v", event)
+	}
+	a.Observe(types.Result{Target: "db", Outcome: types.OutcomeDown})
+	event = receive(t, events)
+	if event["event_action"] != "trigger" || event["dedup_key"] != "monitor:device:db" {
+		t.Errorf("got %v", event)
+	}
+	select {
+	case alert := <-hookAlerts:
+		t.Errorf("startup alert sent to a webhook: %s", alert.body)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -0,0 +1,189 @@
+package notify
+
+import (
+	"bytes"
+	"errors"
+	htmltemplate "html/template"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/types"
+)
+
+const (
+	DefaultSubject = `[{{.Name}}] {{.Target}} is {{.Status}}`
+	DefaultText    = `target: {{.Target}}
+status: {{.Status}}
+{{- if .PreviousStatus}}
+previous: {{.PreviousStatus}}{{end}}
+device: {{.DeviceId}}
+time: {{.Timestamp}}
+{{- if .Duration}}
+duration: {{duration .Duration}}{{end}}
+{{- if .Error}}
+error: {{.Error}}{{end}}
+{{- if .Results}}
+
+recent results:
+{{- range .Results}}
+{{.Timestamp}} {{.Outcome}} {{.Latency}}ms{{if .Detail}}{{if .Detail.Error}} {{.Detail.Error}}{{end}}{{end}}
+{{- end}}{{end}}
+`
+	DefaultHtml = `<html><body>
+<h3>[{{.Name}}] {{.Target}} is {{.Status}}</h3>
+<table>
+<tr><th align="left">target</th><td>{{.Target}}</td></tr>
+<tr><th align="left">status</th><td>{{.Status}}</td></tr>
+{{- if .PreviousStatus}}
+<tr><th align="left">previous</th><td>{{.PreviousStatus}}</td></tr>{{end}}
+<tr><th align="left">device</th><td>{{.DeviceId}}</td></tr>
+<tr><th align="left">time</th><td>{{.Timestamp}}</td></tr>
+{{- if .Duration}}
+<tr><th align="left">duration</th><td>{{duration .Duration}}</td></tr>{{end}}
+{{- if .Error}}
+<tr><th align="left">error</th><td>{{.Error}}</td></tr>{{end}}
+</table>
+{{- if .Results}}
+<h4>recent results</h4>
+<table>
+{{- range .Results}}
+<tr><td>{{.Timestamp}}</td><td>{{.Outcome}}</td><td>{{.Latency}}ms</td><td>{{if .Detail}}{{.Detail.Error}}{{end}}</td></tr>
+{{- end}}
+</table>{{end}}
+</body></html>
+`
+)
+
+// Message is an alert rendered by the templates of a notifier
+type Message struct {
+	Alert   types.Alert `json:"-"`
+	Subject string      `json:"subject"`
+	Text    string      `json:"text"`
+	Html    string      `json:"html"`
+}
+
+var funcs = map[string]interface{}{
+	// duration formats the second of Alert.Duration like 1m30s
+	"duration": func(second int64) string {
+		return (time.Duration(second) * time.Second).String()
+	},
+}
+
+// Render reads the template files of the notifier and renders the alert
+func Render(templates types.Templates, alert types.Alert) (Message, error) {
+	sources, err := load(templates)
+	if err != nil {
+		return Message{Alert: alert}, err
+	}
+	return RenderSource(sources, alert)
+}
+
+// Preview renders the alert with the inline template text of preview, a field left empty
+// uses the template file of the named notifier or the built-in template
+func Preview(notifiers []types.Notifier, preview types.TemplatePreview, alert types.Alert) (Message, error) {
+	var templates types.Templates
+	if preview.Notifier != "" {
+		found := false
+		for _, notifier := range notifiers {
+			if notifier.Name == preview.Notifier {
+				templates = notifier.Templates
+				found = true
+			}
+		}
+		if !found {
+			return Message{Alert: alert}, errors.New("notifier not found: " + preview.Notifier)
+		}
+	}
+	sources, err := load(templates)
+	if err != nil {
+		return Message{Alert: alert}, err
+	}
+	if preview.Subject != "" {
+		sources.Subject = preview.Subject
+	}
+	if preview.Text != "" {
+		sources.Text = preview.Text
+	}
+	if preview.Html != "" {
+		sources.Html = preview.Html
+	}
+	return RenderSource(sources, alert)
+}
+
+// load returns the template text of the files, the built-in template when a file is not set
+func load(templates types.Templates) (types.Templates, error) {
+	sources := types.Templates{Subject: DefaultSubject, Text: DefaultText, Html: DefaultHtml}
+	for _, file := range []struct {
+		path   string
+		source *string
+	}{
+		{templates.Subject, &sources.Subject},
+		{templates.Text, &sources.Text},
+		{templates.Html, &sources.Html},
+	} {
+		if file.path == "" {
+			continue
+		}
+		content, err := os.ReadFile(file.path)
+		if err != nil {
+			return sources, err
+		}
+		*file.source = string(content)
+	}
+	return sources, nil
+}
+
+// RenderSource renders the alert with the given template text
+func RenderSource(sources types.Templates, alert types.Alert) (Message, error) {
+	message := Message{Alert: alert}
+	subject, err := renderText("subject", sources.Subject, alert)
+	if err != nil {
+		return message, err
+	}
+	// a subject is a single header line
+	message.Subject = strings.Join(strings.Fields(subject), " ")
+	message.Text, err = renderText("text", sources.Text, alert)
+	if err != nil {
+		return message, err
+	}
+	tmpl, err := htmltemplate.New("html").Funcs(funcs).Parse(sources.Html)
+	if err != nil {
+		return message, err
+	}
+	var b bytes.Buffer
+	err = tmpl.Execute(&b, alert)
+	message.Html = b.String()
+	return message, err
+}
+
+func renderText(name string, source string, alert types.Alert) (string, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Parse(source)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	err = tmpl.Execute(&b, alert)
+	return b.String(), err
+}
+
+// SampleAlert is a recovery of a made up target, used to try templates
+func SampleAlert(name string, deviceId string) types.Alert {
+	now := time.Now()
+	results := []types.Result{
+		{Timestamp: now.Add(-3 * time.Minute).Format("2006-01-02 15:04:05 -0700"), Status: "503", Target: "example", Latency: 120, Outcome: types.OutcomeDown, Detail: &types.Detail{Error: "unexpected status code 503"}},
+		{Timestamp: now.Add(-2 * time.Minute).Format("2006-01-02 15:04:05 -0700"), Status: "timeout", Target: "example", Latency: 10000, Outcome: types.OutcomeDown, Detail: &types.Detail{Error: "context deadline exceeded"}},
+		{Timestamp: now.Add(-time.Minute).Format("2006-01-02 15:04:05 -0700"), Status: "200", Target: "example", Latency: 85, Outcome: types.OutcomeUp, Detail: &types.Detail{}},
+	}
+	return types.Alert{
+		Name:           name,
+		DeviceId:       deviceId,
+		Target:         "example",
+		Status:         types.OutcomeUp,
+		PreviousStatus: types.OutcomeDown,
+		Timestamp:      results[2].Timestamp,
+		Duration:       120,
+		Results:        results,
+	}
+}
@@ -0,0 +1,38 @@
+package notify
+
+import (
+	"errors"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/types"
+	"github.com/go-resty/resty/v2"
+)
+
+// Webhook posts the alert as json to the notifier url, a text template replaces the json body
+func Webhook(notifier types.Notifier, message Message) error {
+	if notifier.Templates.Text != "" {
+		return post(notifier.Url, notifier.Headers, "application/json", message.Text)
+	}
+	return post(notifier.Url, notifier.Headers, "application/json", message.Alert)
+}
+
+// post sends the body, headers may override the content type and any non 2xx response is an error
+func post(url string, headers map[string]string, contentType string, body interface{}) error {
+	if url == "" {
+		return errors.New("notifier url is required")
+	}
+	resp, err := resty.New().
+		SetTimeout(10*time.Second).
+		R().
+		SetHeader("Content-Type", contentType).
+		SetHeaders(headers).
+		SetBody(body).
+		Post(url)
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return errors.New("notifier response status: " + resp.Status() + " body: " + resp.String())
+	}
+	return nil
+}
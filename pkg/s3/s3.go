@@ -27,7 +27,7 @@ func (basics BucketBasics) ListObjects(bucketName string) ([]types.Object, error
 	})
 	var contents []types.Object
 	if err != nil {
-		logger.Error("Couldn't list objects in bucket %v. Here's why: %v\n", bucketName, err)
+		logger.Errorf("Couldn't list objects in bucket %v. Here's why: %v\n", bucketName, err)
 	} else {
 		contents = result.Contents
 	}
@@ -52,20 +52,20 @@ func (basics BucketBasics) DownloadFile(bucketName string, objectKey string, fil
 		Key:    aws.String(objectKey),
 	})
 	if err != nil {
-		logger.Error("Couldn't get object %v:%v. Here's why: %v\n", bucketName, objectKey, err)
+		logger.Errorf("Couldn't get object %v:%v. Here's why: %v\n", bucketName, objectKey, err)
 		return err
 	}
 	defer result.Body.Close()
 	logger.Debug("end download data:", fileName)
 	file, err := os.Create(fileName)
 	if err != nil {
-		logger.Error("Couldn't create file %v. Here's why: %v\n", fileName, err)
+		logger.Errorf("Couldn't create file %v. Here's why: %v\n", fileName, err)
 		return err
 	}
 	defer file.Close()
 	body, err := io.ReadAll(result.Body)
 	if err != nil {
-		logger.Error("Couldn't read object body from %v. Here's why: %v\n", objectKey, err)
+		logger.Errorf("Couldn't read object body from %v. Here's why: %v\n", objectKey, err)
 	}
 	_, err = file.Write(body)
 	return err
@@ -79,7 +79,7 @@ func (basics BucketBasics) CreateBucket(name string, region string) error {
 		},
 	})
 	if err != nil {
-		logger.Error("Couldn't create bucket %v in Region %v. Here's why: %v\n",
+		logger.Errorf("Couldn't create bucket %v in Region %v. Here's why: %v\n",
 			name, region, err)
 	}
 	return err
@@ -99,12 +99,12 @@ func (basics BucketBasics) BucketExists(bucketName string) (bool, error) {
 				exists = false
 				err = nil
 			default:
-				logger.Error("Either you don't have access to bucket %v or another error occurred. "+
+				logger.Errorf("Either you don't have access to bucket %v or another error occurred. "+
 					"Here's what happened: %v\n", bucketName, err)
 			}
 		}
 	} else {
-		logger.Error("Bucket %v exists and you already own it.", bucketName)
+		logger.Errorf("Bucket %v exists and you already own it.", bucketName)
 	}
 
 	return exists, err
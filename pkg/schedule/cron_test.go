@@ -0,0 +1,90 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		spec  string
+		valid bool
+	}{
+		{"*/5 * * * *", true},
+		{"0 9-17/2 * * 1-5", true},
+		{"0,30 * 1,15 * *", true},
+		{"0 0 * * 7", true},
+		{"0 0 29 2 *", true},
+		{"0 0 31 2 1", true}, // the day-of-week still fires
+		{"@daily", true},
+		{"@weekly", true},
+		{"* * * *", false},
+		{"60 * * * *", false},
+		{"0 24 * * *", false},
+		{"0 0 0 * *", false},
+		{"0 0 * 13 *", false},
+		{"0 0 * * 8", false},
+		{"*/0 * * * *", false},
+		{"5-1 * * * *", false},
+		{"a * * * *", false},
+		{"@often", false},
+		{"0 0 31 2 *", false},
+		{"0 0 30,31 2 *", false},
+		{"0 0 31 4,6,9,11 *", false},
+	}
+	for _, test := range tests {
+		c, err := ParseCron(test.spec)
+		if (err == nil) != test.valid {
+			t.Errorf("%q: got err %v, want valid %v", test.spec, err, test.valid)
+		}
+		if err == nil && c.Next(time.Now()).IsZero() {
+			t.Errorf("%q: parsed but never fires", test.spec)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// a monday
+	base := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"5-10/5 * * * *", time.Date(2024, 1, 15, 11, 5, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2024, 1, 15, 13, 0, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2024, 1, 16, 10, 30, 0, 0, time.UTC)},
+		{"0 12 * * 1-5", time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * *", time.Date(2024, 2, 13, 0, 0, 0, 0, time.UTC)},
+		// a restricted day-of-month and day-of-week match either
+		{"0 0 1,20 * 5", time.Date(2024, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 16 * 5", time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * 3 *", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"@midnight", time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@annually", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		c, err := ParseCron(test.spec)
+		if err != nil {
+			t.Errorf("%q: %v", test.spec, err)
+			continue
+		}
+		if next := c.Next(base); !next.Equal(test.next) {
+			t.Errorf("%q: got %s, want %s", test.spec, next, test.next)
+		}
+	}
+
+	// the next leap day is four years out
+	c, _ := ParseCron("0 0 29 2 *")
+	if next := c.Next(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)); !next.Equal(time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("leap day: got %s", next)
+	}
+}
@@ -0,0 +1,148 @@
+package schedule
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"elpsykongroo.com/monitor/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.New()
+
+type job struct {
+	name      string
+	spec      string
+	every     time.Duration
+	cron      *Cron
+	jitter    time.Duration
+	immediate bool
+	run       func()
+	last      time.Time
+	next      time.Time
+	running   bool
+}
+
+// Scheduler runs every job in its own goroutine, a job never overlaps itself
+type Scheduler struct {
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+func New() *Scheduler {
+	return &Scheduler{jobs: make(map[string]*job)}
+}
+
+// Add starts the job, every is used when the schedule has no spec and
+// immediate when it does not say whether to run at once
+func (s *Scheduler) Add(name string, schedule types.Schedule, every time.Duration, immediate bool, run func()) error {
+	j := &job{name: name, every: every, spec: every.String(), immediate: immediate, run: run}
+	if schedule.Spec != "" {
+		j.spec = schedule.Spec
+		if duration, err := time.ParseDuration(schedule.Spec); err == nil {
+			j.every = duration
+		} else {
+			c, err := ParseCron(schedule.Spec)
+			if err != nil {
+				return err
+			}
+			j.cron = c
+		}
+	}
+	if j.cron == nil && j.every <= 0 {
+		logger.Warn("job without schedule is not started:", name)
+		return nil
+	}
+	if schedule.Jitter != "" {
+		jitter, err := time.ParseDuration(schedule.Jitter)
+		if err != nil {
+			return err
+		}
+		j.jitter = jitter
+	}
+	if schedule.Immediate != nil {
+		j.immediate = *schedule.Immediate
+	}
+	s.mu.Lock()
+	s.jobs[name] = j
+	s.mu.Unlock()
+	logger.Info("schedule job:", name, " spec:", j.spec)
+	go s.loop(j)
+	return nil
+}
+
+func (s *Scheduler) loop(j *job) {
+	now := time.Now()
+	if j.immediate {
+		s.execute(j)
+		now = time.Now()
+	}
+	for {
+		base := j.nextAfter(now)
+		if base.IsZero() {
+			logger.Error("job has no next run:", j.name)
+			return
+		}
+		next := base
+		if j.jitter > 0 {
+			next = base.Add(time.Duration(rand.Int63n(int64(j.jitter))))
+		}
+		s.mu.Lock()
+		j.next = next
+		s.mu.Unlock()
+		time.Sleep(time.Until(next))
+		s.execute(j)
+		// a slow run skips the missed schedules like time.Tick drops ticks
+		now = time.Now()
+		if j.cron == nil && now.Before(base.Add(j.every)) {
+			now = base
+		}
+	}
+}
+
+// nextAfter is the next scheduled time without jitter
+func (j *job) nextAfter(now time.Time) time.Time {
+	if j.cron != nil {
+		return j.cron.Next(now)
+	}
+	return now.Add(j.every)
+}
+
+func (s *Scheduler) execute(j *job) {
+	s.mu.Lock()
+	j.running = true
+	j.last = time.Now()
+	s.mu.Unlock()
+	defer func() {
+		if err := recover(); err != nil {
+			logger.Error("job panic:", j.name, " ", err)
+		}
+		s.mu.Lock()
+		j.running = false
+		s.mu.Unlock()
+	}()
+	j.run()
+}
+
+// Jobs returns the status of every job sorted by name
+func (s *Scheduler) Jobs() []types.JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]types.JobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		status := types.JobStatus{Name: j.name, Spec: j.spec, Running: j.running}
+		if !j.last.IsZero() {
+			status.LastRun = j.last.Format("2006-01-02 15:04:05 -0700")
+		}
+		if !j.next.IsZero() {
+			status.NextRun = j.next.Format("2006-01-02 15:04:05 -0700")
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, k int) bool {
+		return statuses[i].Name < statuses[k].Name
+	})
+	return statuses
+}
@@ -8,48 +8,515 @@ type BucketBasics struct {
 	S3Client *s3.Client
 }
 
+const (
+	OutcomeUp       = "up"
+	OutcomeDown     = "down"
+	OutcomeDegraded = "degraded"
+	// OutcomeMaintenance marks results recorded inside a maintenance window
+	OutcomeMaintenance = "maintenance"
+	// OutcomeUnreachable marks a failed target while one of its parents is down
+	OutcomeUnreachable = "unreachable"
+)
+
+const (
+	CheckHTTP        = "http"
+	CheckTCP         = "tcp"
+	CheckTLS         = "tls"
+	CheckDNS         = "dns"
+	CheckICMP        = "icmp"
+	CheckExec        = "exec"
+	CheckTransaction = "transaction"
+	CheckGRPC        = "grpc"
+	CheckWebSocket   = "websocket"
+	CheckProcess     = "process"
+	CheckContainer   = "container"
+)
+
+const (
+	NotifyWebhook   = "webhook"
+	NotifyEmail     = "email"
+	NotifyTelegram  = "telegram"
+	NotifySlack     = "slack"
+	NotifyDiscord   = "discord"
+	NotifyNtfy      = "ntfy"
+	NotifyGotify    = "gotify"
+	NotifyPagerDuty = "pagerduty"
+	NotifyOpsgenie  = "opsgenie"
+)
+
 type HealthData struct {
-	Timestamp string `json:"x"`
-	Status    string `json:"y"`
+	Timestamp      string   `json:"x"`
+	Status         string   `json:"y"`
+	Target         string   `json:"target"`
+	Latency        int64    `json:"latency"`
+	Outcome        string   `json:"outcome"`
+	CertExpiryDays *int     `json:"certExpiryDays,omitempty"`
+	RootCause      []string `json:"rootCause,omitempty"`
 }
 
 type HealthWithPrivateData struct {
-	Timestamp string `json:"x"`
-	Status    string `json:"y"`
-	Origin    string `json:"origin"`
+	Timestamp      string         `json:"x"`
+	Status         string         `json:"y"`
+	Origin         string         `json:"origin"`
+	Target         string         `json:"target"`
+	Latency        int64          `json:"latency"`
+	Outcome        string         `json:"outcome"`
+	CertExpiryDays *int           `json:"certExpiryDays,omitempty"`
+	Error          string         `json:"error,omitempty"`
+	Timing         *Timing        `json:"timing,omitempty"`
+	Banner         string         `json:"banner,omitempty"`
+	Tls            *TlsInfo       `json:"tls,omitempty"`
+	Answers        []string       `json:"answers,omitempty"`
+	Ping           *PingStats     `json:"ping,omitempty"`
+	Output         string         `json:"output,omitempty"`
+	Metrics        []Metric       `json:"metrics,omitempty"`
+	Steps          []StepResult   `json:"steps,omitempty"`
+	Processes      []ProcessInfo  `json:"processes,omitempty"`
+	Container      *ContainerInfo `json:"container,omitempty"`
+	Maintenance    string         `json:"maintenance,omitempty"`
+	RootCause      []string       `json:"rootCause,omitempty"`
+}
+
+// Result is one probe of a target, stored as a csv row:
+// timestamp, status, origin, target, latency(ms), outcome, detail(json)
+type Result struct {
+	Timestamp string
+	Status    string
+	Origin    string
+	Target    string
+	Latency   int64
+	Outcome   string
+	Detail    *Detail
+}
+
+// Detail holds the check specific data of a result
+type Detail struct {
+	StatusCode   int            `json:"statusCode,omitempty"`
+	Error        string         `json:"error,omitempty"`
+	Attempts     int            `json:"attempts,omitempty"`
+	ProbeOutcome string         `json:"probeOutcome,omitempty"`
+	Timing       *Timing        `json:"timing,omitempty"`
+	Banner       string         `json:"banner,omitempty"`
+	Tls          *TlsInfo       `json:"tls,omitempty"`
+	Answers      []string       `json:"answers,omitempty"`
+	Ping         *PingStats     `json:"ping,omitempty"`
+	Output       string         `json:"output,omitempty"`
+	Metrics      []Metric       `json:"metrics,omitempty"`
+	Steps        []StepResult   `json:"steps,omitempty"`
+	Processes    []ProcessInfo  `json:"processes,omitempty"`
+	Container    *ContainerInfo `json:"container,omitempty"`
+	Maintenance  string         `json:"maintenance,omitempty"`
+	// RootCause is the chain of down parents of an unreachable result, the last one is the root
+	RootCause []string `json:"rootCause,omitempty"`
+}
+
+type TlsInfo struct {
+	Version     string       `json:"version"`
+	CipherSuite string       `json:"cipherSuite"`
+	Certificate *Certificate `json:"certificate,omitempty"`
+}
+
+// Certificate is the peer leaf certificate
+type Certificate struct {
+	Subject    string   `json:"subject"`
+	Issuer     string   `json:"issuer"`
+	NotBefore  string   `json:"notBefore"`
+	NotAfter   string   `json:"notAfter"`
+	DNSNames   []string `json:"dnsNames"`
+	ExpiryDays int      `json:"expiryDays"`
+}
+
+// PingStats is the round trip time of an icmp burst in millisecond
+type PingStats struct {
+	Sent     int     `json:"sent"`
+	Received int     `json:"received"`
+	Loss     float64 `json:"loss"`
+	Min      float64 `json:"min"`
+	Avg      float64 `json:"avg"`
+	Max      float64 `json:"max"`
+	Jitter   float64 `json:"jitter"`
+}
+
+// Metric is one nagios perfdata item, thresholds keep the range syntax
+type Metric struct {
+	Label string  `json:"label"`
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit,omitempty"`
+	Warn  string  `json:"warn,omitempty"`
+	Crit  string  `json:"crit,omitempty"`
+	Min   string  `json:"min,omitempty"`
+	Max   string  `json:"max,omitempty"`
+}
+
+// StepResult is the outcome of one request of a transaction check
+type StepResult struct {
+	Name    string  `json:"name"`
+	Status  string  `json:"status"`
+	Latency int64   `json:"latency"`
+	Outcome string  `json:"outcome"`
+	Error   string  `json:"error,omitempty"`
+	Timing  *Timing `json:"timing,omitempty"`
+}
+
+// ProcessInfo is a matched process, Cpu is the average percent since it started
+type ProcessInfo struct {
+	Pid  int32   `json:"pid"`
+	Name string  `json:"name"`
+	Cpu  float64 `json:"cpu"`
+	Rss  uint64  `json:"rss"`
+}
+
+type ContainerInfo struct {
+	Id           string `json:"id"`
+	Image        string `json:"image"`
+	State        string `json:"state"`
+	Health       string `json:"health,omitempty"`
+	StartedAt    string `json:"startedAt"`
+	RestartCount int    `json:"restartCount"`
+}
+
+// Timing is the http request phases in millisecond
+type Timing struct {
+	DNS       float64 `json:"dns"`
+	Connect   float64 `json:"connect"`
+	TLS       float64 `json:"tls"`
+	FirstByte float64 `json:"firstByte"`
+	Transfer  float64 `json:"transfer"`
+}
+
+type Target struct {
+	Type             string            `json:"type"`
+	Name             string            `json:"name"`
+	Url              string            `json:"url"`
+	Interval         int               `json:"interval"`
+	Timeout          int               `json:"timeout"`
+	Tags             []string          `json:"tags"`
+	Assertions       Assertions        `json:"assertions"`
+	Method           string            `json:"method"`
+	Headers          map[string]string `json:"headers"`
+	Body             string            `json:"body"`
+	Auth             Auth              `json:"auth"`
+	NoRedirect       bool              `json:"noRedirect"`
+	MaxRedirects     int               `json:"maxRedirects"`
+	Tls              TlsOptions        `json:"tls"`
+	Retries          int               `json:"retries"`
+	RetryDelay       int               `json:"retryDelay"`
+	FailureThreshold int               `json:"failureThreshold"`
+	SuccessThreshold int               `json:"successThreshold"`
+	Send             string            `json:"send"`
+	Expect           string            `json:"expect"`
+	CertExpiryDays   int               `json:"certExpiryDays"`
+	Resolver         string            `json:"resolver"`
+	RecordType       string            `json:"recordType"`
+	Answers          []string          `json:"answers"`
+	Count            int               `json:"count"`
+	Command          []string          `json:"command"`
+	Env              map[string]string `json:"env"`
+	Steps            []Step            `json:"steps"`
+	Service          string            `json:"service"`
+	Process          ProcessMatch      `json:"process"`
+	Container        string            `json:"container"`
+	Schedule         Schedule          `json:"schedule"`
+	Parents          []string          `json:"parents"`
+	Notify           []string          `json:"notify"`
+	Recipients       []string          `json:"recipients"`
+}
+
+// Step is one request of a transaction check, "${name}" in url, headers and body
+// is replaced by the value captured in previous steps
+type Step struct {
+	Name       string            `json:"name"`
+	Method     string            `json:"method"`
+	Url        string            `json:"url"`
+	Headers    map[string]string `json:"headers"`
+	Body       string            `json:"body"`
+	Assertions Assertions        `json:"assertions"`
+	Capture    []Capture         `json:"capture"`
+}
+
+// Capture stores a value of the response into a variable, from one of JsonPath,
+// Header or Regex (first group when the regex has one)
+type Capture struct {
+	Name     string `json:"name"`
+	JsonPath string `json:"jsonPath"`
+	Header   string `json:"header"`
+	Regex    string `json:"regex"`
+}
+
+// ProcessMatch selects processes by pid file, or by name and cmdline regex
+type ProcessMatch struct {
+	Name    string `json:"name"`
+	Cmdline string `json:"cmdline"`
+	Pidfile string `json:"pidfile"`
+}
+
+// Auth is basic or bearer auth, VaultPath is a kv path read by the vault user
+// which may hold username, password and token
+type Auth struct {
+	Type      string `json:"type"`
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	Token     string `json:"token"`
+	VaultPath string `json:"vaultPath"`
+}
+
+type TlsOptions struct {
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"`
+	CaFile             string `json:"caFile"`
+	ServerName         string `json:"serverName"`
+}
+
+type Assertions struct {
+	StatusCodes     []string          `json:"statusCodes"`
+	BodyContains    string            `json:"bodyContains"`
+	BodyRegex       string            `json:"bodyRegex"`
+	JsonPath        []JsonAssertion   `json:"jsonPath"`
+	Headers         map[string]string `json:"headers"`
+	MaxResponseTime int64             `json:"maxResponseTime"`
+}
+
+// JsonAssertion checks the value at path equals Equals, or only exists when Equals is nil
+type JsonAssertion struct {
+	Path   string  `json:"path"`
+	Equals *string `json:"equals"`
+}
+
+// Heartbeat is a push monitor, a check-in is expected every Period seconds with Grace seconds tolerance
+type Heartbeat struct {
+	Name       string   `json:"name"`
+	Token      string   `json:"token"`
+	Period     int      `json:"period"`
+	Grace      int      `json:"grace"`
+	Tags       []string `json:"tags"`
+	Notify     []string `json:"notify"`
+	Recipients []string `json:"recipients"`
+}
+
+// HostMetrics is one sample of the device resources
+type HostMetrics struct {
+	Timestamp    string        `json:"x"`
+	Cpu          float64       `json:"cpu"`
+	Load1        float64       `json:"load1"`
+	Load5        float64       `json:"load5"`
+	Load15       float64       `json:"load15"`
+	Memory       Usage         `json:"memory"`
+	Swap         Usage         `json:"swap"`
+	Disks        []DiskUsage   `json:"disks"`
+	Net          []NetCounter  `json:"net"`
+	Temperatures []Temperature `json:"temperatures"`
+}
+
+// Usage is in bytes
+type Usage struct {
+	Total       uint64  `json:"total"`
+	Used        uint64  `json:"used"`
+	UsedPercent float64 `json:"usedPercent"`
+}
+
+type DiskUsage struct {
+	Mount  string `json:"mount"`
+	Fstype string `json:"fstype"`
+	Usage
+}
+
+type NetCounter struct {
+	Name        string `json:"name"`
+	BytesSent   uint64 `json:"bytesSent"`
+	BytesRecv   uint64 `json:"bytesRecv"`
+	PacketsSent uint64 `json:"packetsSent"`
+	PacketsRecv uint64 `json:"packetsRecv"`
+	Errin       uint64 `json:"errin"`
+	Errout      uint64 `json:"errout"`
+}
+
+type Temperature struct {
+	Sensor string  `json:"sensor"`
+	Value  float64 `json:"value"`
+}
+
+// Schedule runs a job on Spec, a go duration like "90s" or a cron expression like "*/5 * * * *",
+// a random delay up to Jitter is added to every run
+type Schedule struct {
+	Spec      string `json:"spec"`
+	Jitter    string `json:"jitter"`
+	Immediate *bool  `json:"immediate"`
+}
+
+// JobStatus is the state of a scheduled job
+type JobStatus struct {
+	Name    string `json:"name"`
+	Spec    string `json:"spec"`
+	LastRun string `json:"lastRun"`
+	NextRun string `json:"nextRun"`
+	Running bool   `json:"running"`
+}
+
+// Maintenance silences the listed targets, all targets when empty.
+// A one-off window runs from Start to End, a recurring one starts on the Cron expression and lasts Duration,
+// times use "2006-01-02 15:04:05 -0700" or RFC3339
+type Maintenance struct {
+	Id       string   `json:"id"`
+	Name     string   `json:"name"`
+	Targets  []string `json:"targets"`
+	Start    string   `json:"start"`
+	End      string   `json:"end"`
+	Cron     string   `json:"cron"`
+	Duration string   `json:"duration"`
+}
+
+// Uptime summarizes the results of a target, maintenance results are left out of the percentage
+type Uptime struct {
+	Target      string  `json:"target"`
+	Total       int     `json:"total"`
+	Up          int     `json:"up"`
+	Degraded    int     `json:"degraded"`
+	Down        int     `json:"down"`
+	Unreachable int     `json:"unreachable"`
+	Maintenance int     `json:"maintenance"`
+	Uptime      float64 `json:"uptime"`
+}
+
+// Connectivity probes independent Endpoints and declares the internet down when Quorum of them fail,
+// every round is stored as a status row of Name
+type Connectivity struct {
+	Name       string   `json:"name"`
+	Endpoints  []Target `json:"endpoints"`
+	Quorum     int      `json:"quorum"`
+	Interval   int      `json:"interval"`
+	IpInterval int      `json:"ipInterval"`
+	Notify     []string `json:"notify"`
+	Recipients []string `json:"recipients"`
+}
+
+// ConnectivityEvent is an internet outage or a public ip change
+type ConnectivityEvent struct {
+	Type     string   `json:"type"`
+	Start    string   `json:"start"`
+	End      string   `json:"end,omitempty"`
+	Duration int64    `json:"duration,omitempty"`
+	Failed   []string `json:"failed,omitempty"`
+	From     string   `json:"from,omitempty"`
+	To       string   `json:"to,omitempty"`
+}
+
+// ConnectivityReport sums up the recorded events, Downtime is in second
+type ConnectivityReport struct {
+	Online    bool                `json:"online"`
+	PublicIp  string              `json:"publicIp"`
+	Outage    *ConnectivityEvent  `json:"outage,omitempty"`
+	Outages   int                 `json:"outages"`
+	IpChanges int                 `json:"ipChanges"`
+	Downtime  int64               `json:"downtime"`
+	Events    []ConnectivityEvent `json:"events"`
+}
+
+// Notifier delivers alerts, a target without notify list uses every notifier.
+// Url is the webhook of webhook, slack and discord and the base url of telegram, ntfy, gotify, pagerduty and opsgenie.
+// Failed deliveries are retried Retries times, the delay in millisecond doubles each time
+type Notifier struct {
+	Name       string            `json:"name"`
+	Type       string            `json:"type"`
+	Url        string            `json:"url"`
+	Headers    map[string]string `json:"headers"`
+	Token      string            `json:"token"`
+	ChatId     string            `json:"chatId"`
+	Topic      string            `json:"topic"`
+	Mentions   []string          `json:"mentions"`
+	Priority   int               `json:"priority"`
+	Retries    *int              `json:"retries"`
+	RetryDelay int               `json:"retryDelay"`
+	Smtp       Smtp              `json:"smtp"`
+	Templates  Templates         `json:"templates"`
+}
+
+// Templates are files of the notification subject and text body in text/template and
+// the email html body in html/template, the built-in template is used when a file is not set
+type Templates struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	Html    string `json:"html"`
+}
+
+// Smtp sends email over Security plain, starttls or tls (implicit),
+// Username and Password are read from VaultPath when it is set
+type Smtp struct {
+	Host      string     `json:"host"`
+	Port      int        `json:"port"`
+	Security  string     `json:"security"`
+	Tls       TlsOptions `json:"tls"`
+	Username  string     `json:"username"`
+	Password  string     `json:"password"`
+	VaultPath string     `json:"vaultPath"`
+	From      string     `json:"from"`
+	To        []string   `json:"to"`
+}
+
+// TemplatePreview is inline template text rendered against a sample alert,
+// an empty field uses the template of Notifier or the built-in one
+type TemplatePreview struct {
+	Notifier string `json:"notifier"`
+	Subject  string `json:"subject"`
+	Text     string `json:"text"`
+	Html     string `json:"html"`
+}
+
+// Alert is a confirmed state transition of a target, Duration is the second spent in
+// the previous status so a recovery carries the outage duration.
+// It is the data of notification templates, Results are the recent results of the target
+type Alert struct {
+	Name           string   `json:"name"`
+	DeviceId       string   `json:"deviceId"`
+	Target         string   `json:"target"`
+	Status         string   `json:"status"`
+	PreviousStatus string   `json:"previousStatus"`
+	Timestamp      string   `json:"timestamp"`
+	Duration       int64    `json:"duration"`
+	Error          string   `json:"error,omitempty"`
+	Results        []Result `json:"-"`
+	Recipients     []string `json:"-"`
 }
 
 type Config struct {
-	Bucket          string `json:"bucket"`
-	Endpoint        string `json:"endpoint"`
-	Region          string `json:"region"`
-	Name            string `json:"name"`
-	MonitorUrl      string `json:"monitorUrl"`
-	IpCheckUrl      string `json:"ipCheckUrl"`
-	ClientId        string `json:"clientId"`
-	ClientSecret    string `json:"clientSecret"`
-	IntrospectUrl   string `json:"introspectUrl"`
-	EnableCheck     bool   `json:"enableCheck"`
-	EnableIpCheck   bool   `json:"enableIpCheck"`
-	EnableQuery     bool   `json:"enableQuery"`
-	EnableUpload    bool   `json:"enableUpload"`
-	EnableSync      bool   `json:"enableSync"`
-	EnableWol       bool   `json:"enableWol"`
-	ForceSync       bool   `json:"forceSync"`
-	CheckDuration   int    `json:"checkDuration"`
-	UploadDuration  int    `json:"uploadDuration"`
-	SyncDuration    int    `json:"syncDuration"`
-	ReportDuration  int    `json:"reportDuration"`
-	Password        string `json:"password"`
-	Username        string `json:"username"`
-	VaultPublicUser string `json:"vaultPublicUser"`
-	VaultUri        string `json:"vaultUri"`
-	VaultCloudUri   string `json:"vaultCloudUri"`
-	VaultConfigPath string `json:"vaultConfigPath"`
-	VaultCustomKey  string `json:"vaultCustomKey"`
-	AcmeEmail       string `json:"acmeEmail"`
-	AcmeDomain      string `json:"acmeDomain"`
-	CfToken         string `json:"cfToken"`
+	Bucket             string              `json:"bucket"`
+	Endpoint           string              `json:"endpoint"`
+	Region             string              `json:"region"`
+	Name               string              `json:"name"`
+	MonitorUrl         string              `json:"monitorUrl"`
+	IpCheckUrl         string              `json:"ipCheckUrl"`
+	ClientId           string              `json:"clientId"`
+	ClientSecret       string              `json:"clientSecret"`
+	IntrospectUrl      string              `json:"introspectUrl"`
+	EnableCheck        bool                `json:"enableCheck"`
+	EnableIpCheck      bool                `json:"enableIpCheck"`
+	EnableQuery        bool                `json:"enableQuery"`
+	EnableUpload       bool                `json:"enableUpload"`
+	EnableSync         bool                `json:"enableSync"`
+	EnableWol          bool                `json:"enableWol"`
+	EnableHeartbeat    bool                `json:"enableHeartbeat"`
+	EnableHostMetrics  bool                `json:"enableHostMetrics"`
+	EnableConnectivity bool                `json:"enableConnectivity"`
+	ForceSync          bool                `json:"forceSync"`
+	CheckDuration      int                 `json:"checkDuration"`
+	UploadDuration     int                 `json:"uploadDuration"`
+	SyncDuration       int                 `json:"syncDuration"`
+	ReportDuration     int                 `json:"reportDuration"`
+	MetricsDuration    int                 `json:"metricsDuration"`
+	Password           string              `json:"password"`
+	Username           string              `json:"username"`
+	VaultPublicUser    string              `json:"vaultPublicUser"`
+	VaultUri           string              `json:"vaultUri"`
+	VaultCloudUri      string              `json:"vaultCloudUri"`
+	VaultConfigPath    string              `json:"vaultConfigPath"`
+	VaultCustomKey     string              `json:"vaultCustomKey"`
+	AcmeEmail          string              `json:"acmeEmail"`
+	AcmeDomain         string              `json:"acmeDomain"`
+	CfToken            string              `json:"cfToken"`
+	Targets            []Target            `json:"targets"`
+	Heartbeats         []Heartbeat         `json:"heartbeats"`
+	Schedules          map[string]Schedule `json:"schedules"`
+	Maintenance        []Maintenance       `json:"maintenance"`
+	Connectivity       Connectivity        `json:"connectivity"`
+	Notifiers          []Notifier          `json:"notifiers"`
 }
 
 type Introspect struct {
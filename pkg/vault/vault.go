@@ -104,6 +104,45 @@ func ReportIpByCheck(config types.Config) {
 	}
 }
 
+// ReadSecret reads a kv secret under VaultUri, kv v2 data is unwrapped
+func ReadSecret(config types.Config, path string) map[string]string {
+	client := resty.New()
+	token := login(config, false)
+	if token == "" {
+		logger.Error("read secret err: vault login failed")
+		return nil
+	}
+	resp, err := client.R().
+		SetHeader("X-Vault-Token", token).
+		Get(config.VaultUri + path)
+	if err != nil {
+		logger.Error("read secret err:", err)
+		return nil
+	}
+
+	var data map[string]interface{}
+	err = json.Unmarshal(resp.Body(), &data)
+	if err != nil {
+		logger.Error("marshal json err:", err)
+		return nil
+	}
+	secret, ok := data["data"].(map[string]interface{})
+	if !ok {
+		logger.Error("secret not exist:", path)
+		return nil
+	}
+	if kv, ok := secret["data"].(map[string]interface{}); ok {
+		secret = kv
+	}
+	values := make(map[string]string)
+	for key, value := range secret {
+		if str, ok := value.(string); ok {
+			values[key] = str
+		}
+	}
+	return values
+}
+
 func login(config types.Config, online bool) string {
 	client := resty.New()
 